@@ -0,0 +1,34 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package command implements all internal commands.
+// This source formats what a command would run in dry-run mode.
+package command
+
+import (
+	"regexp"
+	"strings"
+)
+
+var safeArgRegexp *regexp.Regexp
+
+func init() {
+	safeArgRegexp = regexp.MustCompile("^[a-zA-Z0-9_@%+=:,./-]+$")
+}
+
+// quoteArgs returns the arguments as they could be typed in a shell.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for idx, arg := range args {
+		if safeArgRegexp.MatchString(arg) {
+			quoted[idx] = arg
+		} else {
+			quoted[idx] = "'" + strings.Replace(arg, "'", "'\\''", -1) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// dryRunOutput returns the output shown instead of running the command.
+func dryRunOutput(dir string, args []string) string {
+	return "(dry-run) cd " + quoteArgs([]string{dir}) + " && " + quoteArgs(args)
+}
@@ -0,0 +1,21 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package command
+
+import "testing"
+
+func TestQuoteArgs(t *testing.T) {
+	tests := map[string][]string{
+		"git push origin master":         {"git", "push", "origin", "master"},
+		"rm -rf build":                   {"rm", "-rf", "build"},
+		"git commit -m 'two words'":      {"git", "commit", "-m", "two words"},
+		"echo 'it'\\''s' ''":             {"echo", "it's", ""},
+		"git remote add nas ssh://nas/x": {"git", "remote", "add", "nas", "ssh://nas/x"},
+	}
+
+	for expected, args := range tests {
+		if quoted := quoteArgs(args); quoted != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, quoted)
+		}
+	}
+}
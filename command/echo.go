@@ -10,7 +10,8 @@ import (
 )
 
 type cmdEcho struct {
-	args []string
+	macros repository.Macros
+	err    error
 }
 
 func NewEchoCommand() cmdEcho {
@@ -31,15 +32,26 @@ Useful for testing macros.`
 }
 
 func (cmd cmdEcho) Init(args []string, interactive bool) (outCmd repository.Command) {
-	cmd.args = args
+	cmd.macros, cmd.err = repository.ParseMacros(args)
 	return cmd
 }
 
+func (cmd cmdEcho) InitError() error {
+	return cmd.err
+}
+
 func (cmd cmdEcho) IsInteractive() bool {
 	return false
 }
 
 func (cmd cmdEcho) Run(repository repository.Repository) (outRepository repository.Repository, output bool) {
+	args, err := repository.ApplyMacros(cmd.macros)
+	if err != nil {
+		repository.PutInfo(outputInfo, repository.GetShowName()+": "+err.Error())
+		repository.PutInfo(exitCodeInfo, -1)
+	} else {
+		repository.PutInfo(outputInfo, strings.Join(args, " "))
+	}
 	return repository, true
 }
 
@@ -52,5 +64,5 @@ func (cmd cmdEcho) Footer() string {
 }
 
 func (cmd cmdEcho) Output(repository repository.Repository) string {
-	return strings.Join(repository.ReplaceMacros(cmd.args), " ")
+	return repository.GetInfo(outputInfo).(string)
 }
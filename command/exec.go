@@ -5,74 +5,242 @@
 package command
 
 import (
+	"errors"
+	"flag"
 	"github.com/marcelfw/mgit/engine"
 	"github.com/marcelfw/mgit/repository"
-	"os"
-	"os/exec"
 	"strings"
 )
 
 type cmdExec struct {
-	args []string
+	macros repository.Macros
+	err    error
 
-	interactive bool
+	commandOptions
+
+	dryRun bool
+	shell  bool // run the arguments as a shell script
+
+	baseArgs []string // configured command, the arguments are added to it
+	script   string   // configured shell script, the arguments are passed to it
+
+	defaultArgs []string // configured arguments, added before the other arguments
+}
+
+// cmdBuiltinExec is the builtin exec command, which has a flag to run the arguments as a shell script.
+// Configured commands have no flags, so all their arguments are passed on.
+type cmdBuiltinExec struct {
+	cmdExec
+
+	shellFlag *bool
+}
+
+// NewExecCommand returns the builtin exec command, vars is its command section (which may be nil).
+func NewExecCommand(vars map[string]string) cmdBuiltinExec {
+	var cmd cmdBuiltinExec
+
+	if value, ok := vars["confirm"]; ok {
+		cmd.confirm = isYes(value)
+	}
+
+	return cmd
+}
+
+// NewShellCommand returns the builtin sh command, vars is its command section (which may be nil).
+func NewShellCommand(vars map[string]string) cmdExec {
+	cmd := NewExecCommand(vars).cmdExec
+
+	cmd.shell = true
+
+	return cmd
+}
+
+func (cmd cmdBuiltinExec) AddFlags(flags *flag.FlagSet) repository.Command {
+	cmd.shellFlag = flags.Bool("shell", false, "run the arguments as a shell script")
+
+	return cmd
+}
+
+func (cmd cmdBuiltinExec) Init(args []string, interactive bool) (outCmd repository.Command) {
+	if cmd.shellFlag != nil && *cmd.shellFlag {
+		cmd.shell = true
+	}
+	return cmd.cmdExec.Init(args, interactive)
 }
 
-func NewExecCommand() cmdExec {
+// NewConfigExecCommand returns a command which runs a configured program or shell script.
+func NewConfigExecCommand(value string, shell bool, vars map[string]string) cmdExec {
 	var cmd cmdExec
 
+	if shell {
+		cmd.shell = true
+		cmd.script = value
+	} else {
+		cmd.baseArgs, cmd.err = splitArgs(value)
+	}
+
+	if value, ok := vars["args"]; ok {
+		// for scripts these are passed before the other arguments
+		if args, err := splitArgs(value); err != nil {
+			cmd.err = err
+		} else {
+			cmd.defaultArgs = args
+		}
+	}
+
+	options, err := readOptions(vars, "Run \""+value+"\".")
+	if err != nil && cmd.err == nil {
+		cmd.err = err
+	}
+	cmd.commandOptions = options
+
 	return cmd
 }
 
 func (cmd cmdExec) Usage() string {
+	if cmd.usage != "" {
+		return cmd.usage
+	}
+	if cmd.shell {
+		return "Execute a shell script."
+	}
 	return "Execute a command."
 }
 
 func (cmd cmdExec) Help() string {
+	if cmd.help != "" {
+		return cmd.help
+	}
+	if cmd.script != "" {
+		return cmd.usage + "\n\nRuns with $SHELL -c \"" + cmd.script + "\", arguments are passed as $1, $2, ..."
+	}
+	if cmd.baseArgs != nil {
+		return cmd.usage + "\n\nArguments are added to \"" + strings.Join(cmd.baseArgs, " ") + "\"."
+	}
+	if cmd.shell {
+		return `Execute a shell script.
+
+Performs macro conversion and runs the script with $SHELL -c, so pipes,
+redirection, && and globs work. Same as "exec -shell".
+
+The environment describes the repository, see "exec".`
+	}
 	return `Execute a command.
 
-Performs macro conversion and runs the command(s).`
+Performs macro conversion and runs the command(s).
+With -shell the arguments run as a shell script (see "sh").
+
+The environment contains:
+  MGIT_NAME    Name of the repository
+  MGIT_PATH    Work directory
+  MGIT_GITDIR  Git directory
+  MGIT_BRANCH  Current branch
+  MGIT_INDEX   Position of the repository (starting at 1)
+  MGIT_TOTAL   Number of repositories
+  MGIT_ROOT    Root directory the repositories were found in`
 }
 
 func (cmd cmdExec) Init(args []string, interactive bool) (outCmd repository.Command) {
-	cmd.args = args
-	cmd.interactive = interactive
+	args = append(append([]string{}, cmd.defaultArgs...), args...)
+
+	switch {
+	case cmd.script != "":
+		args = append([]string{cmd.script}, args...)
+	case cmd.baseArgs != nil:
+		args = append(append([]string{}, cmd.baseArgs...), args...)
+	}
+
+	if cmd.err == nil {
+		if len(args) == 0 {
+			cmd.err = errors.New("Missing command to execute.")
+		} else {
+			cmd.macros, cmd.err = repository.ParseMacros(args)
+		}
+	}
+	cmd.interactive = cmd.interactive || interactive
 	return cmd
 }
 
+func (cmd cmdExec) InitError() error {
+	return cmd.err
+}
+
+// commandArgs returns the command with arguments as it would run for the repository.
+func (cmd cmdExec) commandArgs(repos repository.Repository) ([]string, error) {
+	args, err := repos.ApplyMacros(cmd.macros)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.script != "" {
+		// "mgit" becomes $0 of the script
+		return append([]string{repository.GetShell(), "-c", args[0], "mgit"}, args[1:]...), nil
+	}
+	if cmd.shell {
+		return []string{repository.GetShell(), "-c", strings.Join(args, " ")}, nil
+	}
+	return args, nil
+}
+
+func (cmd cmdExec) DryRun() repository.Command {
+	cmd.dryRun = true
+	cmd.interactive = false
+	return cmd
+}
+
+func (cmd cmdExec) NeedsConfirmation() bool {
+	return cmd.confirm
+}
+
+func (cmd cmdExec) Preview(repository repository.Repository) string {
+	args, err := cmd.commandArgs(repository)
+	if err != nil {
+		return err.Error()
+	}
+	return quoteArgs(args)
+}
+
+// NeedsPosition returns true, because MGIT_INDEX and MGIT_TOTAL are in the environment.
+func (cmd cmdExec) NeedsPosition() bool {
+	return true
+}
+
+func (cmd cmdExec) Jobs() int {
+	return cmd.jobs
+}
+
+func (cmd cmdExec) Template() string {
+	return cmd.template
+}
+
 func (cmd cmdExec) IsInteractive() bool {
 	return cmd.interactive
 }
 
 func (cmd cmdExec) Run(repository repository.Repository) (outRepository repository.Repository, output bool) {
-	args := repository.ReplaceMacros(cmd.args)
-	extCmd := exec.Command(args[0], args[1:]...)
-	extCmd.Dir = repository.GetPath()
+	args, err := cmd.commandArgs(repository)
+	if err != nil {
+		repository.PutInfo(outputInfo, err.Error())
+		repository.PutInfo(exitCodeInfo, -1)
+		return repository, true
+	}
+	if cmd.dryRun {
+		repository.PutInfo(outputInfo, dryRunOutput(repository.GetPath(), args))
+		return repository, true
+	}
 
-	repository.PutInfo("exec", "")
+	repository.PutInfo(outputInfo, "")
 
+	result, err := runProcess(repository, args, cmd.timeout, cmd.interactive)
 	if cmd.interactive {
-		extCmd.Stdin = os.Stdin
-		extCmd.Stdout = os.Stdout
-		extCmd.Stderr = os.Stderr
-
-		if err := extCmd.Start(); err != nil {
-			repository.PutInfo("exec", err)
+		if err != nil {
+			repository.PutInfo(outputInfo, err.Error())
+			repository.PutInfo(exitCodeInfo, exitCode(err))
 			return repository, false
 		}
-
-		if err := extCmd.Wait(); err != nil {
-			repository.PutInfo("exec", err)
-			return repository, false
-		}
-
-		repository.PutInfo("exec", "Ok")
-	} else {
-		result, err := extCmd.CombinedOutput()
-		if err == nil {
-			repository.PutInfo("exec", strings.TrimSpace(string(result)))
-		}
+		result = "Ok"
 	}
+	repository.PutInfo(outputInfo, result)
+	repository.PutInfo(exitCodeInfo, exitCode(err))
 	return repository, true
 }
 
@@ -86,5 +254,5 @@ func (cmd cmdExec) Header() []string {
 }
 
 func (cmd cmdExec) Output(repository repository.Repository) interface{} {
-	return engine.FormatRow(repository.GetShowName(), repository.GetInfo("exec").(string))
+	return engine.FormatRow(repository.GetShowName(), repository.GetInfo(outputInfo).(string))
 }
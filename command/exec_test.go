@@ -0,0 +1,118 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+func TestExecConfirm(t *testing.T) {
+	tests := []struct {
+		cmd      cmdExec
+		expected bool
+	}{
+		{NewExecCommand(nil).cmdExec, false},
+		{NewShellCommand(nil), false},
+		{NewExecCommand(map[string]string{"confirm": "yes"}).cmdExec, true},
+		{NewShellCommand(map[string]string{"confirm": "yes"}), true},
+		{NewConfigExecCommand("make", false, map[string]string{}), false},
+		{NewConfigExecCommand("make", false, map[string]string{"confirm": "yes"}), true},
+	}
+
+	for i, test := range tests {
+		if confirm := test.cmd.NeedsConfirmation(); confirm != test.expected {
+			t.Errorf("Test %d: expected %v, got %v", i, test.expected, confirm)
+		}
+	}
+}
+
+func TestExecShellFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"ls", "-la"}, []string{"ls", "-la"}},
+		{[]string{"-shell", "ls", "|", "wc"}, []string{repository.GetShell(), "-c", "ls | wc"}},
+		{[]string{"echo", "-shell"}, []string{"echo", "-shell"}},
+	}
+
+	for _, test := range tests {
+		flags := flag.NewFlagSet("exec", flag.ContinueOnError)
+		cmd := NewExecCommand(nil).AddFlags(flags)
+		if err := flags.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		args, err := cmd.Init(flags.Args(), false).(cmdExec).commandArgs(repository.Repository{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("Expected '%v' to run '%v', got '%v'", test.args, test.expected, args)
+		}
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	cmd := NewConfigExecCommand("sleep 5 | cat", true, map[string]string{"timeout": "1s"}).Init(nil, false).(cmdExec)
+	if err := cmd.InitError(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	outRepository, _ := cmd.Run(repository.Repository{})
+	if duration := time.Since(start); duration > 3*time.Second {
+		t.Errorf("Expected the script to stop after the timeout, took %v", duration)
+	}
+	if output := outRepository.GetInfo(outputInfo).(string); !strings.Contains(output, "timed out after 1s") {
+		t.Errorf("Expected timeout in output, got '%s'", output)
+	}
+	if exitCode := outRepository.GetInfo(exitCodeInfo).(int); exitCode == 0 {
+		t.Errorf("Expected a failed exit code")
+	}
+}
+
+func TestGitProxyTimeout(t *testing.T) {
+	if err := NewGitProxyCommand("pull", map[string]string{"timeout": "soon"}).InitError(); err == nil {
+		t.Error("Expected an invalid timeout to fail")
+	}
+
+	vars := map[string]string{"args": "alias.nap='!sleep 5' nap", "timeout": "1s"}
+	cmd := NewGitProxyCommand("-c", vars).Init(nil, false).(cmdGitProxy)
+	if err := cmd.InitError(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	outRepository, _ := cmd.Run(repository.Repository{})
+	if duration := time.Since(start); duration > 3*time.Second {
+		t.Errorf("Expected git to stop after the timeout, took %v", duration)
+	}
+	if output := outRepository.GetInfo(outputInfo).(string); !strings.Contains(output, "timed out after 1s") {
+		t.Errorf("Expected timeout in output, got '%s'", output)
+	}
+}
+
+func TestGitProxyPosition(t *testing.T) {
+	if NewGitProxyCommand("status", map[string]string{}).NeedsPosition() {
+		t.Error("Expected builtin git commands to start while repositories are found")
+	}
+
+	vars := map[string]string{"git": "-c", "args": "alias.pos='!echo $MGIT_INDEX/$MGIT_TOTAL' pos"}
+	cmd := NewGitProxyCommand(vars["git"], vars).Init(nil, false).(cmdGitProxy)
+	if !cmd.NeedsPosition() {
+		t.Error("Expected configured git commands to need the position")
+	}
+
+	var repos repository.Repository
+	repos.SetPosition(1, 3)
+	outRepository, _ := cmd.Run(repos)
+	if output := outRepository.GetInfo(outputInfo).(string); output != "2/3" {
+		t.Errorf("Expected the position in the environment of git, got '%s'", output)
+	}
+}
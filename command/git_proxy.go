@@ -12,36 +12,38 @@ import (
 )
 
 type cmdGitProxy struct {
-	interactive bool
+	dryRun   bool
+	position bool // needs MGIT_INDEX and MGIT_TOTAL
 
 	command string
 	args    []string
+	macros  repository.Macros
+	err     error
 
-	usage string
-	help  string
+	commandOptions
 }
 
 func NewGitProxyCommand(command string, vars map[string]string) cmdGitProxy {
 	var cmd cmdGitProxy
 
 	cmd.command = command
+	// configured commands often run aliases or hooks which can use the position, builtin ones
+	// start while repositories are still being found
+	_, cmd.position = vars["git"]
 	cmd.args = make([]string, 0, 10)
 	cmd.args = append(cmd.args, command)
 
-	cmd.usage = "Run \"git " + command + "\"."
-
-	if value, ok := vars["usage"]; ok {
-		cmd.usage = value
-	}
-	if value, ok := vars["help"]; ok {
-		cmd.help = value
+	if value, ok := vars["args"]; ok {
+		var args []string
+		args, cmd.err = splitArgs(value)
+		cmd.args = append(cmd.args, args...)
 	}
 
-	if value, ok := vars["interactive"]; ok {
-		if value == "yes" || value == "1" || value == "true" {
-			cmd.interactive = true
-		}
+	options, err := readOptions(vars, "Run \"git "+command+"\".")
+	if err != nil && cmd.err == nil {
+		cmd.err = err
 	}
+	cmd.commandOptions = options
 
 	return cmd
 }
@@ -66,28 +68,70 @@ func (cmd cmdGitProxy) Help() string {
 
 func (cmd cmdGitProxy) Init(args []string, interactive bool) (outCmd repository.Command) {
 	cmd.args = append(cmd.args, args...)
-	cmd.interactive = interactive
+	if cmd.err == nil {
+		cmd.macros, cmd.err = repository.ParseMacros(cmd.args)
+	}
+	cmd.interactive = cmd.interactive || interactive
 	return cmd
 }
 
+func (cmd cmdGitProxy) InitError() error {
+	return cmd.err
+}
+
+func (cmd cmdGitProxy) DryRun() repository.Command {
+	cmd.dryRun = true
+	cmd.interactive = false
+	return cmd
+}
+
+func (cmd cmdGitProxy) NeedsConfirmation() bool {
+	return cmd.confirm
+}
+
+func (cmd cmdGitProxy) Preview(repository repository.Repository) string {
+	args, err := repository.ApplyMacros(cmd.macros)
+	if err != nil {
+		return err.Error()
+	}
+	return quoteArgs(append([]string{"git"}, args...))
+}
+
+// NeedsPosition returns true for configured commands, so MGIT_INDEX and MGIT_TOTAL are in the environment.
+func (cmd cmdGitProxy) NeedsPosition() bool {
+	return cmd.position
+}
+
+func (cmd cmdGitProxy) Template() string {
+	return cmd.template
+}
+
+func (cmd cmdGitProxy) Jobs() int {
+	return cmd.jobs
+}
+
 func (cmd cmdGitProxy) IsInteractive() bool {
 	return cmd.interactive
 }
 
 func (cmd cmdGitProxy) Run(repository repository.Repository) (outRepository repository.Repository, output bool) {
-	args := repository.ReplaceMacros(cmd.args)
+	args, err := repository.ApplyMacros(cmd.macros)
 
-	if cmd.interactive {
-		_ = repository.ExecGitInteractive(args...)
+	if err != nil {
+		repository.PutInfo(outputInfo, err.Error())
+		repository.PutInfo(exitCodeInfo, -1)
+	} else if cmd.dryRun {
+		repository.PutInfo(outputInfo, dryRunOutput(repository.GetPath(), append([]string{"git"}, args...)))
+	} else if cmd.interactive {
+		_, _ = runProcess(repository, append([]string{"git"}, args...), cmd.timeout, true)
 
-		repository.PutInfo("proxy."+cmd.command, "(interactive command ran)")
+		repository.PutInfo(outputInfo, "(interactive command ran)")
 	} else {
-		var result string
-
 		// we just want the return anything, even if it is an error
-		result, _, _ = repository.ExecGit(args...)
+		result, err := runProcess(repository, append([]string{"git"}, args...), cmd.timeout, false)
 
-		repository.PutInfo("proxy."+cmd.command, strings.TrimSpace(result))
+		repository.PutInfo(outputInfo, result)
+		repository.PutInfo(exitCodeInfo, exitCode(err))
 	}
 
 	return repository, true
@@ -104,5 +148,5 @@ func (cmd cmdGitProxy) Header() []string {
 
 // Output returns the result of the command
 func (cmd cmdGitProxy) Output(repository repository.Repository) interface{} {
-	return engine.FormatRow(repository.GetShowName(), repository.GetInfo("proxy."+cmd.command).(string))
+	return engine.FormatRow(repository.GetShowName(), repository.GetInfo(outputInfo).(string))
 }
@@ -5,9 +5,78 @@
 package command
 
 import (
+	"flag"
+	"github.com/marcelfw/mgit/engine"
 	"github.com/marcelfw/mgit/repository"
+	"sort"
+	"strings"
 )
 
+// helpTopics are help subjects which are not commands.
+var helpTopics = map[string]string{
+	"macros": `Macros in arguments.
+
+Arguments of echo, exec, sh, Git commands and the "if" filter are Go text
+templates. The following values are available:
+
+  {{ .Name }}                 Name of the repository
+  {{ .Path }}                 Work directory
+  {{ .GitDir }}               Git directory
+  {{ .CurrentBranch }}        Current branch
+  {{ .DefaultBranch }}        Branch HEAD of origin points to (master if unknown)
+  {{ .Upstream }}             Upstream of the current branch
+  {{ .Ahead }}                Commits not in the upstream
+  {{ .Behind }}               Upstream commits not in the current branch
+  {{ .Status }}               Status summary like in "list"
+  {{ .Remotes.origin }}       Url of remote "origin"
+  {{ .LastCommit.Hash }}      Hash of the last commit
+  {{ .LastCommit.Author }}    Author of the last commit (also .Email)
+  {{ .LastCommit.Date }}      Date of the last commit
+  {{ .LastCommit.Subject }}   Subject of the last commit
+  {{ .Tags }}                 Tags pointing at HEAD
+  {{ .Labels }}               Configured labels
+
+Values which need git are only retrieved when used.
+Macros are checked before any repository is touched, unknown values and
+wrong arguments are errors. When a macro fails for a repository, the error
+is shown for that repository only. When it fails for every repository mgit
+exits with an error.
+
+Functions (in a pipeline the value is passed as the last argument):
+
+  lower, upper, title, trim          {{ .Name | upper }}
+  trimprefix, trimsuffix <text>      {{ .Name | trimsuffix "-old" }}
+  replace <old> <new>                {{ .Remotes.origin | replace "git@" "https://" }}
+  contains, hasprefix, hassuffix     {{ if hasprefix "customer/" .Name }}..{{ end }}
+  split <sep>, join <sep>            {{ .Tags | join "," }}
+  base, dir, ext, clean, abs         {{ .Name | base }}
+  pathjoin <elem>...                 {{ pathjoin "/backup" .Name }}
+  https, ssh, urlhost                {{ .Remotes.origin | https }}
+  env <name>                         {{ env "HOME" }}
+  default <value>                    {{ .Remotes.origin | default "none" }}
+  now, date <layout>                 {{ .LastCommit.Time | date "2006-01-02" }}
+
+Output templates (-template) can also use:
+
+  {{ .Output }}               Output of the command
+  {{ .ExitCode }}             Exit code of the command
+  {{ .Duration }}             Time the command took
+  {{ .Info }}                 Everything the command stored
+
+Example:
+  mgit echo "{{ .Name }} {{ range .Tags }}{{ . }} {{ end }}"`,
+}
+
+// HelpTopics returns the help subjects which are not commands.
+func HelpTopics() []string {
+	topics := make([]string, 0, len(helpTopics))
+	for topic := range helpTopics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
 type cmdHelp struct {
 	command string
 }
@@ -28,7 +97,10 @@ func (cmd cmdHelp) Help() string {
 	}
 	return `Show help information.
 
-Add command as argument to help for more information about the command.`
+Add command as argument to help for more information about the command.
+
+Other topics are:
+  macros   Macros in arguments`
 }
 
 func (cmd cmdHelp) Init(args []string, interactive bool) (outCmd repository.Command) {
@@ -39,9 +111,32 @@ func (cmd cmdHelp) Init(args []string, interactive bool) (outCmd repository.Comm
 	return nil
 }
 
+// flagUsage returns a table with the flags of the command.
+func flagUsage(flagCommand repository.FlagCommand) string {
+	flags := flag.NewFlagSet("help", flag.ContinueOnError)
+	flagCommand.AddFlags(flags)
+
+	rows := make([][]string, 0, 5)
+	flags.VisitAll(func(commandFlag *flag.Flag) {
+		name, usage := flag.UnquoteUsage(commandFlag)
+		if name != "" {
+			name = " <" + name + ">"
+		}
+		rows = append(rows, []string{"  -" + commandFlag.Name + name, usage})
+	})
+
+	return strings.TrimRight(engine.ReturnTextTable(nil, rows), "\n")
+}
+
 func (cmd cmdHelp) Output(commands map[string]repository.Command, version string) string {
 	if helpCommand, ok := commands[cmd.command]; ok == true {
+		if flagCommand, ok := helpCommand.(repository.FlagCommand); ok {
+			return helpCommand.Help() + "\n\nOptions:\n" + flagUsage(flagCommand)
+		}
 		return helpCommand.Help()
 	}
+	if topic, ok := helpTopics[cmd.command]; ok {
+		return topic
+	}
 	return cmd.Help()
 }
@@ -5,18 +5,43 @@
 package command
 
 import (
+	"flag"
+	"fmt"
 	"github.com/marcelfw/mgit/repository"
-	"strconv"
 	"strings"
 	"time"
 )
 
+// listColumns are all columns list can show.
+var listColumns = []struct {
+	key    string
+	header string
+}{
+	{"name", "Name"},
+	{"branch", "Branch"},
+	{"status", "Status"},
+	{"commit", "Last commit"},
+	{"subject", "Subject"},
+	{"author", "Author"},
+	{"path", "Path"},
+}
+
+// columns shown when no columns are given
+var defaultListColumns = []string{"name", "branch", "status", "commit", "subject"}
+
 type cmdList struct {
+	columnsFlag *string
+	allFlag     *bool
+
+	columns []string // keys of the columns to show
+	err     error
 }
 
 func NewListCommand() cmdList {
 	var cmd cmdList
 
+	cmd.columns = defaultListColumns
+
 	return cmd
 }
 
@@ -32,12 +57,53 @@ Shown are:
   Branch   Current branch
   Status   Status summary of repository
   Commit   Last author commit date
-  Subject  Subject of last commit`
+  Subject  Subject of last commit
+
+Also available are author and path, e.g. "list -columns name,author".`
+}
+
+func (cmd cmdList) AddFlags(flags *flag.FlagSet) repository.Command {
+	keys := make([]string, 0, len(listColumns))
+	for _, column := range listColumns {
+		keys = append(keys, column.key)
+	}
+
+	cmd.columnsFlag = flags.String("columns", "", "columns to show ("+strings.Join(keys, ", ")+")")
+	cmd.allFlag = flags.Bool("all", false, "show all columns")
+
+	return cmd
 }
 
 func (cmd cmdList) Init(args []string, interactive bool) (outCmd repository.Command) {
-	// we don't do anything
-	return nil
+	switch {
+	case cmd.allFlag != nil && *cmd.allFlag:
+		cmd.columns = make([]string, 0, len(listColumns))
+		for _, column := range listColumns {
+			cmd.columns = append(cmd.columns, column.key)
+		}
+	case cmd.columnsFlag != nil && *cmd.columnsFlag != "":
+		cmd.columns = repository.SplitList(strings.ToLower(*cmd.columnsFlag))
+		for _, key := range cmd.columns {
+			if listHeader(key) == "" {
+				cmd.err = fmt.Errorf("Unknown column \"%s\".", key)
+			}
+		}
+	}
+	return cmd
+}
+
+func (cmd cmdList) InitError() error {
+	return cmd.err
+}
+
+// listHeader returns the header of the column, empty if there is no such column.
+func listHeader(key string) string {
+	for _, column := range listColumns {
+		if column.key == key {
+			return column.header
+		}
+	}
+	return ""
 }
 
 // Return human readable time.
@@ -60,42 +126,72 @@ func (cmd cmdList) IsInteractive() bool {
 }
 
 func (cmd cmdList) Run(repository repository.Repository) (outRepository repository.Repository, output bool) {
-	log, _, _ := repository.ExecGit("log", "--max-count=1", "--format=%an : %ae : %at : %s")
-	results := strings.SplitN(strings.TrimRight(log, "\r\n"), " : ", 4)
-
 	repository.PutInfo("list.name", "-")
 	repository.PutInfo("list.email", "-")
 	repository.PutInfo("list.time", "-")
 	repository.PutInfo("list.subject", "-")
 
-	if len(results) == 4 {
-		repository.PutInfo("list.name", results[0])
-		repository.PutInfo("list.email", results[1])
-		if unixtime, err := strconv.ParseInt(results[2], 10, 0); err == nil {
-
-			repository.PutInfo("list.time", cmd.getHumanTime(time.Unix(int64(unixtime), 0)))
-			//repository.PutInfo("list.time", results[2])
-		}
-		repository.PutInfo("list.subject", results[3])
+	if commit, ok := repository.GetLastCommit(); ok {
+		repository.PutInfo("list.name", commit.Author)
+		repository.PutInfo("list.email", commit.Email)
+		repository.PutInfo("list.time", cmd.getHumanTime(commit.Time))
+		repository.PutInfo("list.subject", commit.Subject)
 	}
 
 	return repository, true
 }
 
 func (cmd cmdList) Header() []string {
-	return []string{
-		"Name", "Branch", "Status", "Last commit", "Subject",
+	columns := make([]string, 0, len(cmd.columns))
+	for _, key := range cmd.columns {
+		columns = append(columns, listHeader(key))
 	}
+	return columns
 }
 
 func (cmd cmdList) Output(repository repository.Repository) interface{} {
-	columns := make([]string, 5, 5)
-
-	columns[0] = repository.GetShowName()
-	columns[1] = repository.GetCurrentBranch()
-	columns[2] = repository.GetStatusJudgement()
-	columns[3] = repository.GetInfo("list.time").(string)
-	columns[4] = repository.GetInfo("list.subject").(string)
+	columns := make([]string, 0, len(cmd.columns))
+
+	for _, key := range cmd.columns {
+		switch key {
+		case "name":
+			columns = append(columns, repository.GetShowName())
+		case "branch":
+			columns = append(columns, repository.GetCurrentBranch())
+		case "status":
+			columns = append(columns, repository.GetStatusJudgement())
+		case "commit":
+			columns = append(columns, repository.GetInfo("list.time").(string))
+		case "subject":
+			columns = append(columns, repository.GetInfo("list.subject").(string))
+		case "author":
+			columns = append(columns, repository.GetInfo("list.name").(string))
+		case "path":
+			columns = append(columns, repository.GetPath())
+		}
+	}
 
 	return columns
 }
+
+func (cmd cmdList) SortColumns() []string {
+	return cmd.Header()
+}
+
+func (cmd cmdList) SortValue(repository repository.Repository, column string) string {
+	if column == "Last commit" {
+		// the human time does not sort, so use the actual time
+		if commit, ok := repository.GetLastCommit(); ok {
+			return commit.Time.UTC().Format(time.RFC3339)
+		}
+		return ""
+	}
+
+	columns := cmd.Output(repository).([]string)
+	for idx, header := range cmd.Header() {
+		if header == column {
+			return columns[idx]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestListColumns(t *testing.T) {
+	cmd := NewListCommand().Init(nil, false).(cmdList)
+	if expected := []string{"Name", "Branch", "Status", "Last commit", "Subject"}; !reflect.DeepEqual(cmd.Header(), expected) {
+		t.Errorf("Expected default header '%v', got '%v'", expected, cmd.Header())
+	}
+
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	cmd = NewListCommand().AddFlags(flags).(cmdList)
+	if err := flags.Parse([]string{"-columns", "Name, author"}); err != nil {
+		t.Fatal(err)
+	}
+	cmd = cmd.Init(flags.Args(), false).(cmdList)
+	if expected := []string{"Name", "Author"}; cmd.InitError() != nil || !reflect.DeepEqual(cmd.Header(), expected) {
+		t.Errorf("Expected header '%v', got '%v' (error '%v')", expected, cmd.Header(), cmd.InitError())
+	}
+
+	flags = flag.NewFlagSet("list", flag.ContinueOnError)
+	cmd = NewListCommand().AddFlags(flags).(cmdList)
+	if err := flags.Parse([]string{"-columns", "name,size"}); err != nil {
+		t.Fatal(err)
+	}
+	if cmd = cmd.Init(flags.Args(), false).(cmdList); cmd.InitError() == nil {
+		t.Error("Expected unknown column to fail")
+	}
+}
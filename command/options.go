@@ -0,0 +1,183 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package command implements all internal commands.
+// This source reads options of commands from configuration sections.
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commonKeys are the keys every configured command can have.
+var commonKeys = []string{"filters", "usage", "help", "template", "interactive", "confirm", "jobs"}
+
+// configKeys are the keys each kind of command section can have besides commonKeys.
+// Steps and their on-failure can only be in a section of steps.
+var configKeys = map[string][]string{
+	"steps": {"on-failure", "timeout"},
+	"git":   {"git", "args", "timeout"},
+	"exec":  {"exec", "args", "timeout"},
+	"shell": {"shell", "args", "timeout"},
+}
+
+// ConfigKind returns the kind of command a section configures: steps, git, exec or shell.
+// Returns an empty string if the section configures no command (e.g. it configures a builtin command).
+func ConfigKind(vars map[string]string) string {
+	if IsPipeline(vars) {
+		return "steps"
+	}
+	for _, kind := range []string{"git", "exec", "shell"} {
+		if _, ok := vars[kind]; ok {
+			return kind
+		}
+	}
+	return ""
+}
+
+// IsConfigKey returns true if the key is known in a command section of the kind.
+func IsConfigKey(kind, key string) bool {
+	for _, configKey := range append(commonKeys, configKeys[kind]...) {
+		if key == configKey {
+			return true
+		}
+	}
+	return kind == "steps" && stepRegexp.MatchString(strings.TrimSuffix(key, ".on-failure"))
+}
+
+// IsScriptKey returns true if the value of the key in a command section is run (or holds filters
+// which can run scripts), so $VAR belongs to the script.
+func IsScriptKey(key string) bool {
+	switch key {
+	case "git", "exec", "shell", "args", "filters":
+		return true
+	}
+	return stepRegexp.MatchString(key)
+}
+
+// isYes returns true if a configuration value means yes.
+func isYes(value string) bool {
+	return value == "yes" || value == "1" || value == "true"
+}
+
+// commandOptions are the options shared by configured git, exec, shell and step commands.
+type commandOptions struct {
+	interactive bool
+	confirm     bool
+
+	jobs    int
+	timeout time.Duration
+
+	usage    string
+	help     string
+	template string
+}
+
+// readOptions reads the options of a command section, usage is used when the section has none.
+func readOptions(vars map[string]string, usage string) (options commandOptions, err error) {
+	options.usage = usage
+
+	if value, ok := vars["usage"]; ok {
+		options.usage = value
+	}
+	if value, ok := vars["help"]; ok {
+		options.help = value
+	}
+	if value, ok := vars["template"]; ok {
+		options.template = value
+	}
+
+	if value, ok := vars["interactive"]; ok {
+		options.interactive = isYes(value)
+	}
+	if value, ok := vars["confirm"]; ok {
+		options.confirm = isYes(value)
+	}
+
+	if options.jobs, err = readJobs(vars); err != nil {
+		return options, err
+	}
+	options.timeout, err = readTimeout(vars)
+
+	return options, err
+}
+
+// readJobs reads the number of parallel jobs, 0 means the default.
+func readJobs(vars map[string]string) (int, error) {
+	if value, ok := vars["jobs"]; ok {
+		jobs, err := strconv.Atoi(value)
+		if err != nil || jobs < 1 {
+			return 0, fmt.Errorf("Invalid jobs \"%s\", expected a number of at least 1.", value)
+		}
+		return jobs, nil
+	}
+	return 0, nil
+}
+
+// readTimeout reads the timeout for each repository, 0 means no timeout.
+func readTimeout(vars map[string]string) (time.Duration, error) {
+	if value, ok := vars["timeout"]; ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return 0, fmt.Errorf("Invalid timeout \"%s\", expected a duration like 30s or 5m.", value)
+		}
+		return timeout, nil
+	}
+	return 0, nil
+}
+
+// splitArgs splits a command-line from the configuration into arguments.
+// Single and double quotes group words, a backslash escapes the next character.
+// Inside double quotes a backslash only escapes ", \, $ and `, like in the shell.
+func splitArgs(line string) ([]string, error) {
+	args := make([]string, 0, 10)
+
+	var arg []rune
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, char := range line {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", char) {
+				// like the shell, inside double quotes other characters keep the backslash
+				arg = append(arg, '\\')
+			}
+			arg = append(arg, char)
+			escaped = false
+		case char == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				arg = append(arg, char)
+			}
+		case char == '\'' || char == '"':
+			quote = char
+			inArg = true
+		case strings.ContainsRune(" \t\r\n", char):
+			if inArg {
+				args = append(args, string(arg))
+				arg = arg[:0]
+				inArg = false
+			}
+		default:
+			arg = append(arg, char)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("Unterminated quote or escape in \"%s\".", line)
+	}
+	if inArg {
+		args = append(args, string(arg))
+	}
+
+	return args, nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package command
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := map[string][]string{
+		"make build":                 {"make", "build"},
+		"  go   test  ./... ":        {"go", "test", "./..."},
+		`echo "{{ .Name }}" 'a "b"'`: {"echo", "{{ .Name }}", `a "b"`},
+		`grep -r foo\ bar ""`:        {"grep", "-r", "foo bar", ""},
+		`printf "%s\"\n" x`:          {"printf", `%s"\n`, "x"},
+		`echo "\$HOME \\ \a"`:        {"echo", `$HOME \ \a`},
+		`echo a\nb`:                  {"echo", "anb"},
+		"":                           {},
+	}
+
+	for line, expected := range tests {
+		args, err := splitArgs(line)
+		if err != nil {
+			t.Errorf("Expected '%s' to split, got error '%v'", line, err)
+		} else if !reflect.DeepEqual(args, expected) {
+			t.Errorf("Expected '%s' to split into %q, got %q", line, expected, args)
+		}
+	}
+
+	for _, line := range []string{`echo "open`, `echo 'open`, `echo \`} {
+		if _, err := splitArgs(line); err == nil {
+			t.Errorf("Expected '%s' not to split", line)
+		}
+	}
+}
+
+func TestReadOptions(t *testing.T) {
+	options, err := readOptions(map[string]string{"confirm": "yes", "jobs": "2", "timeout": "5m"}, "Run it.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.usage != "Run it." || !options.confirm || options.jobs != 2 || options.timeout != 5*time.Minute {
+		t.Errorf("Unexpected options %+v", options)
+	}
+
+	for _, vars := range []map[string]string{{"jobs": "0"}, {"timeout": "soon"}} {
+		if _, err := readOptions(vars, ""); err == nil {
+			t.Errorf("Expected %v to fail", vars)
+		}
+	}
+}
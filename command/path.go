@@ -0,0 +1,177 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package command implements all internal commands.
+// This source returns the directory of the repository best matching a name.
+package command
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/marcelfw/mgit/repository"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// info name to store how well the repository matches
+const pathScoreInfo = "path.score"
+
+type cmdPath struct {
+	query string
+	err   error
+}
+
+func NewPathCommand() cmdPath {
+	var cmd cmdPath
+
+	return cmd
+}
+
+func (cmd cmdPath) Usage() string {
+	return "Show the directory of the repository best matching a name."
+}
+
+func (cmd cmdPath) Help() string {
+	return `Show the directory of the repository best matching a name.
+
+Names are matched in this order: the exact name, the last part of the name,
+the start of the (last part of the) name, anywhere in the name and finally
+the letters in order (e.g. "mg" matches "mgit"). When several repositories
+match equally well, you are asked to choose one.
+
+Use "." to show the root directory repositories are searched in.
+Exits with an error when nothing matches. See "shell-init" for mcd.`
+}
+
+func (cmd cmdPath) Init(args []string, interactive bool) (outCmd repository.Command) {
+	if len(args) != 1 || args[0] == "" {
+		cmd.err = errors.New("Specify the name of a repository.")
+	} else {
+		cmd.query = args[0]
+	}
+	return cmd
+}
+
+func (cmd cmdPath) InitError() error {
+	return cmd.err
+}
+
+// pathScore returns how well the name matches the query, 0 if it does not match.
+func pathScore(name, query string) int {
+	name = strings.ToLower(name)
+	query = strings.ToLower(query)
+	base := path.Base(name)
+
+	switch {
+	case name == query:
+		return 1000
+	case base == query:
+		return 900
+	case strings.HasPrefix(base, query):
+		return 700
+	case strings.HasPrefix(name, query):
+		return 650
+	case strings.Contains(base, query):
+		return 500
+	case strings.Contains(name, query):
+		return 400
+	}
+
+	// letters in order, every gap lowers the score
+	score := 100
+	next := 0
+	for idx := 0; idx < len(name) && next < len(query); idx++ {
+		if name[idx] == query[next] {
+			next++
+		} else if next > 0 {
+			score--
+		}
+	}
+	if next < len(query) {
+		return 0
+	}
+	if score < 1 {
+		return 1
+	}
+	return score
+}
+
+func (cmd cmdPath) IsInteractive() bool {
+	return false
+}
+
+func (cmd cmdPath) Run(repository repository.Repository) (outRepository repository.Repository, output bool) {
+	if cmd.query == "." {
+		return repository, true
+	}
+
+	score := pathScore(repository.GetShowName(), cmd.query)
+	repository.PutInfo(pathScoreInfo, score)
+
+	return repository, score > 0
+}
+
+func (cmd cmdPath) Reduce(repositories []repository.Repository) (string, error) {
+	if len(repositories) == 0 {
+		if cmd.query == "." {
+			return "", errors.New("No repositories found.")
+		}
+		return "", fmt.Errorf("No repository matches \"%s\".", cmd.query)
+	}
+	if cmd.query == "." {
+		return repository.AbsPath(repositories[0].GetRoot()), nil
+	}
+
+	candidates := make([]repository.Repository, len(repositories))
+	copy(candidates, repositories)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].GetInfo(pathScoreInfo).(int) > candidates[j].GetInfo(pathScoreInfo).(int)
+	})
+
+	best := 1
+	for best < len(candidates) && candidates[best].GetInfo(pathScoreInfo) == candidates[0].GetInfo(pathScoreInfo) {
+		best++
+	}
+	if best == 1 {
+		return repository.AbsPath(candidates[0].GetPath()), nil
+	}
+
+	chosen, err := chooseRepository(cmd.query, candidates[:best])
+	if err != nil {
+		return "", err
+	}
+	return repository.AbsPath(chosen.GetPath()), nil
+}
+
+// chooseRepository asks which repository is meant on the terminal.
+// The question goes to stderr, because the output is used by the shell.
+func chooseRepository(query string, candidates []repository.Repository) (repository.Repository, error) {
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		names = append(names, candidate.GetShowName())
+	}
+
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return repository.Repository{}, fmt.Errorf("\"%s\" matches %s.", query, strings.Join(names, ", "))
+	}
+
+	for idx, name := range names {
+		fmt.Fprintf(os.Stderr, "%2d) %s\n", idx+1, name)
+	}
+	fmt.Fprintf(os.Stderr, "Choose a repository [1-%d]: ", len(names))
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		// input ended without a newline
+		fmt.Fprintln(os.Stderr)
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return repository.Repository{}, errors.New("No repository chosen.")
+	}
+
+	return candidates[choice-1], nil
+}
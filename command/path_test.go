@@ -0,0 +1,48 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package command
+
+import (
+	"testing"
+
+	"github.com/marcelfw/mgit/repository"
+	"github.com/marcelfw/mgit/testutil"
+)
+
+func TestPathScore(t *testing.T) {
+	ordered := []string{"mgit", "tools/mgit", "mgit-old", "mgit/tools", "old-mgit", "old-mgit/tools", "my-git"}
+	previous := 1001
+	for _, name := range ordered {
+		score := pathScore(name, "mgit")
+		if score <= 0 || score >= previous {
+			t.Errorf("Expected '%s' to score lower than the previous name, got %d after %d", name, score, previous)
+		}
+		previous = score
+	}
+
+	if score := pathScore("website", "mgit"); score != 0 {
+		t.Errorf("Expected 'website' not to match, got %d", score)
+	}
+}
+
+func TestPathReduce(t *testing.T) {
+	newRepository := func(name string) repository.Repository {
+		repos, _ := repository.NewRepository(0, name, "/src/"+name+"/.git")
+		outRepository, _ := NewPathCommand().Init([]string{"alpha"}, false).(cmdPath).Run(repos)
+		return outRepository
+	}
+	cmd := NewPathCommand().Init([]string{"alpha"}, false).(cmdPath)
+
+	if dir, err := cmd.Reduce([]repository.Repository{newRepository("sub/alpha"), newRepository("alpha")}); err != nil || dir != "/src/alpha" {
+		t.Errorf("Expected exact match '/src/alpha', got '%s' (error '%v')", dir, err)
+	}
+	if _, err := cmd.Reduce(nil); err == nil {
+		t.Error("Expected no match to fail")
+	}
+	// without a terminal there is no choice
+	testutil.WithStdin(t, "1\n", func() {
+		if _, err := cmd.Reduce([]repository.Repository{newRepository("one/alpha"), newRepository("two/alpha")}); err == nil {
+			t.Error("Expected ambiguous match without a choice to fail")
+		}
+	})
+}
@@ -0,0 +1,333 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package command implements all internal commands.
+// This source runs configured steps one after another.
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/marcelfw/mgit/engine"
+	"github.com/marcelfw/mgit/repository"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// info name to store the failed step
+const failedStepInfo = "pipeline.failed"
+
+var stepRegexp *regexp.Regexp
+
+func init() {
+	stepRegexp = regexp.MustCompile("^step([0-9]+)$")
+}
+
+type pipelineStep struct {
+	line string // as configured
+
+	kind   string // git, exec or shell
+	args   []string
+	macros repository.Macros
+
+	stopOnFailure bool
+}
+
+type cmdPipeline struct {
+	steps []pipelineStep
+	err   error
+
+	commandOptions
+
+	dryRun bool
+}
+
+// IsPipeline returns true if the configuration section defines steps.
+func IsPipeline(vars map[string]string) bool {
+	for key := range vars {
+		if stepRegexp.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnFailure reads an on-failure value and returns true to stop.
+func readOnFailure(value string) (bool, error) {
+	switch value {
+	case "stop":
+		return true, nil
+	case "continue":
+		return false, nil
+	}
+	return true, fmt.Errorf("Invalid on-failure \"%s\", expected stop or continue.", value)
+}
+
+// newPipelineStep parses a step like "git pull --rebase", "exec make" or "shell ls | wc -l".
+func newPipelineStep(line string, stopOnFailure bool) (step pipelineStep, err error) {
+	step.line = line
+	step.stopOnFailure = stopOnFailure
+
+	parts := strings.SplitN(strings.TrimSpace(line), " ", 2)
+	step.kind = parts[0]
+	rest := ""
+	if len(parts) == 2 {
+		rest = strings.TrimSpace(parts[1])
+	}
+
+	switch step.kind {
+	case "git", "exec":
+		if step.args, err = splitArgs(rest); err != nil {
+			return step, err
+		}
+		if len(step.args) == 0 {
+			return step, fmt.Errorf("Missing arguments in step \"%s\".", line)
+		}
+	case "shell":
+		if rest == "" {
+			return step, fmt.Errorf("Missing script in step \"%s\".", line)
+		}
+		step.args = []string{rest}
+	default:
+		return step, fmt.Errorf("Invalid step \"%s\", expected git, exec or shell.", line)
+	}
+
+	step.macros, err = repository.ParseMacros(step.args)
+	return step, err
+}
+
+// NewPipelineCommand returns a command which runs the configured steps for each repository.
+func NewPipelineCommand(vars map[string]string) cmdPipeline {
+	var cmd cmdPipeline
+
+	for _, kind := range []string{"git", "exec", "shell"} {
+		if _, ok := vars[kind]; ok && cmd.err == nil {
+			cmd.err = fmt.Errorf("Steps can't be combined with %s.", kind)
+		}
+	}
+
+	stopOnFailure := true
+	if value, ok := vars["on-failure"]; ok {
+		var err error
+		if stopOnFailure, err = readOnFailure(value); err != nil && cmd.err == nil {
+			cmd.err = err
+		}
+	}
+
+	// steps are ordered by number, so step10 follows step9
+	keys := make(map[int]string)
+	numbers := make([]int, 0, len(vars))
+	for key := range vars {
+		if match := stepRegexp.FindStringSubmatch(key); len(match) >= 2 {
+			number, _ := strconv.Atoi(match[1])
+			if other, ok := keys[number]; ok {
+				if other > key {
+					other, key = key, other
+				}
+				if cmd.err == nil {
+					cmd.err = fmt.Errorf("Steps \"%s\" and \"%s\" have the same number.", other, key)
+				}
+				continue
+			}
+			keys[number] = key
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+
+	lines := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		key := keys[number]
+
+		stepStop := stopOnFailure
+		if value, ok := vars[key+".on-failure"]; ok {
+			var err error
+			if stepStop, err = readOnFailure(value); err != nil && cmd.err == nil {
+				cmd.err = err
+			}
+		}
+
+		step, err := newPipelineStep(vars[key], stepStop)
+		if err != nil && cmd.err == nil {
+			cmd.err = err
+		}
+		cmd.steps = append(cmd.steps, step)
+		lines = append(lines, vars[key])
+	}
+
+	options, err := readOptions(vars, "Run \""+strings.Join(lines, "\", \"")+"\".")
+	if err != nil && cmd.err == nil {
+		cmd.err = err
+	}
+	cmd.commandOptions = options
+
+	return cmd
+}
+
+func (cmd cmdPipeline) Usage() string {
+	return cmd.usage
+}
+
+func (cmd cmdPipeline) Help() string {
+	if cmd.help != "" {
+		return cmd.help
+	}
+
+	help := cmd.usage + "\n\nSteps are:\n"
+	for idx, step := range cmd.steps {
+		onFailure := "stop"
+		if !step.stopOnFailure {
+			onFailure = "continue"
+		}
+		help += fmt.Sprintf("  %d. %s (on failure %s)\n", idx+1, step.line, onFailure)
+	}
+	return strings.TrimRight(help, "\n")
+}
+
+func (cmd cmdPipeline) Init(args []string, interactive bool) (outCmd repository.Command) {
+	if len(args) > 0 && cmd.err == nil {
+		cmd.err = errors.New("Command does not take arguments, use macros in the steps instead.")
+	}
+	cmd.interactive = cmd.interactive || interactive
+	return cmd
+}
+
+func (cmd cmdPipeline) InitError() error {
+	return cmd.err
+}
+
+// stepArgs returns the program with arguments of the step as it would run for the repository.
+func (step pipelineStep) stepArgs(repos repository.Repository) ([]string, error) {
+	args, err := repos.ApplyMacros(step.macros)
+	if err != nil {
+		return nil, err
+	}
+
+	switch step.kind {
+	case "git":
+		return append([]string{"git"}, args...), nil
+	case "shell":
+		return []string{repository.GetShell(), "-c", args[0]}, nil
+	}
+	return args, nil
+}
+
+func (cmd cmdPipeline) DryRun() repository.Command {
+	cmd.dryRun = true
+	cmd.interactive = false
+	return cmd
+}
+
+func (cmd cmdPipeline) NeedsConfirmation() bool {
+	return cmd.confirm
+}
+
+func (cmd cmdPipeline) Preview(repository repository.Repository) string {
+	previews := make([]string, 0, len(cmd.steps))
+	for _, step := range cmd.steps {
+		args, err := step.stepArgs(repository)
+		if err != nil {
+			return err.Error()
+		}
+		previews = append(previews, quoteArgs(args))
+	}
+	return strings.Join(previews, "; ")
+}
+
+// NeedsPosition returns true, because MGIT_INDEX and MGIT_TOTAL are in the environment.
+func (cmd cmdPipeline) NeedsPosition() bool {
+	return true
+}
+
+func (cmd cmdPipeline) Jobs() int {
+	return cmd.jobs
+}
+
+func (cmd cmdPipeline) Template() string {
+	return cmd.template
+}
+
+func (cmd cmdPipeline) IsInteractive() bool {
+	return cmd.interactive
+}
+
+func (cmd cmdPipeline) Run(repository repository.Repository) (outRepository repository.Repository, output bool) {
+	lines := make([]string, 0, len(cmd.steps)*2)
+	lastExitCode := 0
+
+	repository.PutInfo(failedStepInfo, "")
+
+	for idx, step := range cmd.steps {
+		args, err := step.stepArgs(repository)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("[%d] %s", idx+1, err))
+			repository.PutInfo(failedStepInfo, fmt.Sprintf("%d. %s", idx+1, step.line))
+			lastExitCode = -1
+			break
+		}
+
+		lines = append(lines, fmt.Sprintf("[%d] %s", idx+1, quoteArgs(args)))
+		if cmd.dryRun {
+			continue
+		}
+
+		stepOutput, err := runProcess(repository, args, cmd.timeout, cmd.interactive)
+		exitCode := exitCode(err)
+		if stepOutput != "" {
+			lines = append(lines, stepOutput)
+		}
+
+		if exitCode != 0 {
+			lastExitCode = exitCode
+			lines = append(lines, fmt.Sprintf("step %d failed with exit code %d", idx+1, exitCode))
+			if repository.GetInfo(failedStepInfo) == "" {
+				repository.PutInfo(failedStepInfo, fmt.Sprintf("%d. %s", idx+1, step.line))
+			}
+			if step.stopOnFailure {
+				break
+			}
+		}
+	}
+
+	if cmd.dryRun {
+		repository.PutInfo(outputInfo, "(dry-run) cd "+quoteArgs([]string{repository.GetPath()})+"\n"+strings.Join(lines, "\n"))
+	} else {
+		repository.PutInfo(outputInfo, strings.Join(lines, "\n"))
+	}
+	repository.PutInfo(exitCodeInfo, lastExitCode)
+
+	return repository, true
+}
+
+func (cmd cmdPipeline) Header() []string {
+	columns := make([]string, 3, 3)
+
+	columns[0] = "Repository"
+	columns[1] = "Failed step"
+	columns[2] = "Output"
+
+	return columns
+}
+
+func (cmd cmdPipeline) Output(repository repository.Repository) interface{} {
+	failed := repository.GetInfo(failedStepInfo).(string)
+
+	output := engine.FormatRow(repository.GetShowName(), repository.GetInfo(outputInfo).(string))
+	switch output.(type) {
+	case []string:
+		columns := output.([]string)
+		return []string{columns[0], failed, columns[1]}
+	case [][]string:
+		rows := output.([][]string)
+		for idx, row := range rows {
+			step := ""
+			if idx == 0 {
+				step = failed
+			}
+			rows[idx] = []string{row[0], step, row[1]}
+		}
+		return rows
+	}
+	return output
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+func TestPipelineSteps(t *testing.T) {
+	tests := []struct {
+		line     string
+		kind     string
+		args     []string
+		hasError bool
+	}{
+		{"git pull --rebase", "git", []string{"pull", "--rebase"}, false},
+		{"exec make 'a b'", "exec", []string{"make", "a b"}, false},
+		{"shell ls | wc -l", "shell", []string{"ls | wc -l"}, false},
+		{"git", "", nil, true},
+		{"exec", "", nil, true},
+		{"shell ", "", nil, true},
+		{"run make", "", nil, true},
+		{"git log '", "", nil, true},
+	}
+
+	for i, test := range tests {
+		step, err := newPipelineStep(test.line, true)
+		if (err != nil) != test.hasError {
+			t.Errorf("Test %d: expected error %v, got %v", i, test.hasError, err)
+			continue
+		}
+		if !test.hasError && (step.kind != test.kind || !reflect.DeepEqual(step.args, test.args)) {
+			t.Errorf("Test %d: expected %s %v, got %s %v", i, test.kind, test.args, step.kind, step.args)
+		}
+	}
+}
+
+func TestPipelineCommand(t *testing.T) {
+	cmd := NewPipelineCommand(map[string]string{
+		"step10":            "shell echo ten",
+		"step2":             "shell exit 3",
+		"step2.on-failure":  "continue",
+		"step9":             "shell exit 4",
+		"step11":            "shell echo not reached",
+		"on-failure":        "stop",
+		"usage":             "Run the steps.",
+		"step11.on-failure": "stop",
+	})
+	if err := cmd.InitError(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make([]string, 0, len(cmd.steps))
+	stops := make([]bool, 0, len(cmd.steps))
+	for _, step := range cmd.steps {
+		lines = append(lines, step.line)
+		stops = append(stops, step.stopOnFailure)
+	}
+	if expected := []string{"shell exit 3", "shell exit 4", "shell echo ten", "shell echo not reached"}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected steps in numeric order %v, got %v", expected, lines)
+	}
+	if expected := []bool{false, true, true, true}; !reflect.DeepEqual(stops, expected) {
+		t.Errorf("Expected stop on failure %v, got %v", expected, stops)
+	}
+
+	// step 2 fails and continues, step 9 fails and stops
+	outRepository, _ := cmd.Run(repository.Repository{})
+	if failed := outRepository.GetInfo(failedStepInfo).(string); failed != "1. shell exit 3" {
+		t.Errorf("Expected the first failed step, got '%s'", failed)
+	}
+	if exitCode := outRepository.GetInfo(exitCodeInfo).(int); exitCode != 4 {
+		t.Errorf("Expected exit code 4 of the last failed step, got %d", exitCode)
+	}
+	shell := repository.GetShell()
+	expected := "[1] " + quoteArgs([]string{shell, "-c", "exit 3"}) + "\nstep 1 failed with exit code 3\n" +
+		"[2] " + quoteArgs([]string{shell, "-c", "exit 4"}) + "\nstep 2 failed with exit code 4"
+	if output := outRepository.GetInfo(outputInfo).(string); output != expected {
+		t.Errorf("Expected output '%s', got '%s'", expected, output)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []map[string]string{
+		{"step01": "git fetch", "step1": "git pull"},
+		{"step1": "git"},
+		{"step1": "git pull", "git": "fetch"},
+		{"step1": "git pull", "exec": "make"},
+		{"step1": "git pull", "step1.on-failure": "maybe"},
+		{"step1": "git pull", "on-failure": "never"},
+	}
+
+	for i, vars := range tests {
+		if err := NewPipelineCommand(vars).InitError(); err == nil {
+			t.Errorf("Test %d: expected an error for %v", i, vars)
+		}
+	}
+
+	if err := NewPipelineCommand(map[string]string{"step01": "git fetch", "step1": "git pull"}).InitError(); err.Error() != "Steps \"step01\" and \"step1\" have the same number." {
+		t.Errorf("Expected both steps in the error, got '%v'", err)
+	}
+	if cmd := NewPipelineCommand(map[string]string{"step01": "git fetch"}); cmd.InitError() != nil || cmd.steps[0].line != "git fetch" {
+		t.Errorf("Expected step01 to be read, got %v", cmd.steps)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package command implements all internal commands.
+// This source runs programs for exec, shell and step commands.
+package command
+
+import (
+	"bytes"
+	"context"
+	"github.com/marcelfw/mgit/repository"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// killWaitDelay is how long to wait for the output after a process is killed.
+const killWaitDelay = time.Second
+
+// newProcess returns a process which runs in the repository with the repository environment.
+// With a timeout all processes started by it are killed when it expires, interactive processes
+// stay in the process group of the terminal. Ctrl-C is passed on to the processes, see trackProcess.
+func newProcess(ctx context.Context, repository repository.Repository, args []string, timeout, interactive bool) *exec.Cmd {
+	extCmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	extCmd.Dir = repository.GetPath()
+	extCmd.Env = append(os.Environ(), repository.GetEnvironment()...)
+
+	if timeout && !interactive {
+		killProcessGroup(extCmd)
+	}
+
+	return extCmd
+}
+
+// runProcess runs the program in the repository and returns its output.
+// Interactive programs use the terminal and return no output.
+func runProcess(repository repository.Repository, args []string, timeout time.Duration, interactive bool) (string, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	extCmd := newProcess(ctx, repository, args, timeout > 0, interactive)
+
+	if interactive {
+		extCmd.Stdin = os.Stdin
+		extCmd.Stdout = os.Stdout
+		extCmd.Stderr = os.Stderr
+
+		return "", extCmd.Run()
+	}
+
+	var result bytes.Buffer
+	extCmd.Stdout = &result
+	extCmd.Stderr = &result
+	err := extCmd.Start()
+	if err == nil {
+		done := trackProcess(extCmd)
+		err = extCmd.Wait()
+		done()
+	}
+
+	output := strings.TrimSpace(result.String())
+	if ctx.Err() == context.DeadlineExceeded {
+		output = strings.TrimSpace(output + "\n(timed out after " + timeout.String() + ")")
+	}
+	return output, err
+}
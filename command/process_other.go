@@ -0,0 +1,22 @@
+// Copyright (c) 2014 Marcel Wouters
+
+//go:build !unix
+
+// Package command implements all internal commands.
+// This source stops processes on systems without process groups.
+package command
+
+import (
+	"os/exec"
+)
+
+// killProcessGroup only kills the process itself when the context ends, children may keep running
+// but no longer keep mgit waiting.
+func killProcessGroup(extCmd *exec.Cmd) {
+	extCmd.WaitDelay = killWaitDelay
+}
+
+// trackProcess does nothing, processes stay in the group of the terminal and get Ctrl-C themselves.
+func trackProcess(extCmd *exec.Cmd) func() {
+	return func() {}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2014 Marcel Wouters
+
+//go:build unix
+
+// Package command implements all internal commands.
+// This source stops processes on unix.
+package command
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// process groups which are running, these no longer get the signals of the terminal
+var processGroups = struct {
+	sync.Mutex
+	pids map[int]bool
+}{pids: make(map[int]bool)}
+
+var forwardOnce sync.Once
+
+// killProcessGroup starts the process in its own process group and kills the whole group when the
+// context ends, so children of a shell (like "sleep 5 | cat") stop as well.
+func killProcessGroup(extCmd *exec.Cmd) {
+	extCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	extCmd.Cancel = func() error {
+		return syscall.Kill(-extCmd.Process.Pid, syscall.SIGKILL)
+	}
+	extCmd.WaitDelay = killWaitDelay
+}
+
+// trackProcess remembers a started process with its own process group, so Ctrl-C reaches it.
+// Returns the function to call when the process has ended.
+func trackProcess(extCmd *exec.Cmd) func() {
+	if extCmd.SysProcAttr == nil || !extCmd.SysProcAttr.Setpgid {
+		return func() {}
+	}
+	forwardOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		go forwardSignals(signals)
+	})
+
+	pid := extCmd.Process.Pid
+	processGroups.Lock()
+	processGroups.pids[pid] = true
+	processGroups.Unlock()
+
+	return func() {
+		processGroups.Lock()
+		delete(processGroups.pids, pid)
+		processGroups.Unlock()
+	}
+}
+
+// forwardSignals sends an interrupt to the running process groups and then stops mgit
+// the way the signal would have.
+func forwardSignals(signals chan os.Signal) {
+	sig := (<-signals).(syscall.Signal)
+
+	// the lock is kept until mgit stops, so no more results are shown
+	processGroups.Lock()
+	defer processGroups.Unlock()
+	for pid := range processGroups.pids {
+		_ = syscall.Kill(-pid, sig)
+	}
+
+	signal.Reset(sig)
+	_ = syscall.Kill(os.Getpid(), sig)
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package command implements all internal commands.
+// This source stores the result of a command for templates.
+package command
+
+import (
+	"os/exec"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+// info names used to store the result of a command
+// (repository is shadowed in most commands, so we keep local copies)
+const outputInfo = repository.OutputInfo
+const exitCodeInfo = repository.ExitCodeInfo
+
+// exitCode returns the exit code of a finished command, -1 if it did not run.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
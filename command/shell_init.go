@@ -0,0 +1,143 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package command implements all internal commands.
+// This source returns shell functions to use mgit from the shell.
+package command
+
+import (
+	"fmt"
+	"github.com/marcelfw/mgit/repository"
+	"strings"
+)
+
+const bashShellInit = `# mgit shell functions, add to ~/.bashrc:
+#   eval "$(mgit shell-init bash)"
+
+# mcd goes to the directory of the repository best matching <name>,
+# "mcd ." goes to the root directory.
+mcd()
+{
+    if [ $# -eq 0 ]; then
+        echo "usage: mcd [<filters>] <name>" >&2
+        return 2
+    fi
+@DEFAULTS@    local dir
+    dir="$(command mgit "${@:1:$#-1}" path "${@:$#}")" || return
+    builtin cd -- "$dir"
+}`
+
+const zshShellInit = `# mgit shell functions, add to ~/.zshrc:
+#   eval "$(mgit shell-init zsh)"
+
+# mcd goes to the directory of the repository best matching <name>,
+# "mcd ." goes to the root directory.
+mcd()
+{
+    if (( $# == 0 )); then
+        echo "usage: mcd [<filters>] <name>" >&2
+        return 2
+    fi
+@DEFAULTS@    local dir
+    dir="$(command mgit "${@[1,-2]}" path "${@[-1]}")" || return
+    builtin cd -- "$dir"
+}`
+
+const fishShellInit = `# mgit shell functions, add to ~/.config/fish/config.fish:
+#   mgit shell-init fish | source
+
+# mcd goes to the directory of the repository best matching <name>,
+# "mcd ." goes to the root directory.
+function mcd --description 'Go to the directory of a repository'
+    if test (count $argv) -eq 0
+        echo "usage: mcd [<filters>] <name>" >&2
+        return 2
+    end
+@DEFAULTS@    set -l filters $argv
+    set -e filters[-1]
+    set -l dir (command mgit $filters path $argv[-1]); or return
+    builtin cd $dir
+end`
+
+type cmdShellInit struct {
+	shell   string
+	filters []string // filters used when only a name is given
+}
+
+func NewShellInitCommand() cmdShellInit {
+	var cmd cmdShellInit
+
+	return cmd
+}
+
+func (cmd cmdShellInit) Usage() string {
+	return "Show shell functions like mcd (bash, zsh or fish)."
+}
+
+func (cmd cmdShellInit) Help() string {
+	return `Show shell functions to use mgit from the shell.
+
+  bash   Add 'eval "$(mgit shell-init bash)"' to ~/.bashrc
+  zsh    Add 'eval "$(mgit shell-init zsh)"' to ~/.zshrc
+  fish   Add "mgit shell-init fish | source" to ~/.config/fish/config.fish
+
+Functions are:
+  mcd [<filters>] <name>   Go to the directory of the repository, see "path"
+  mcd .                    Go to the root directory
+
+Filters after the shell are used when mcd gets only a name, e.g.
+"mgit shell-init bash -s global" searches with shortcut "global".`
+}
+
+func (cmd cmdShellInit) Init(args []string, interactive bool) (outCmd repository.Command) {
+	if len(args) >= 1 {
+		cmd.shell = args[0]
+		cmd.filters = args[1:]
+	}
+	return cmd
+}
+
+// quoteFishArgs quotes arguments for fish, which escapes quotes with a backslash.
+func quoteFishArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for idx, arg := range args {
+		quoted[idx] = "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(arg) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+func (cmd cmdShellInit) Output(commands map[string]repository.Command, version string) string {
+	output, _ := cmd.OutputError(commands, version)
+	return output
+}
+
+// OutputError returns the shell functions, with an error for an unknown shell.
+func (cmd cmdShellInit) OutputError(commands map[string]repository.Command, version string) (string, error) {
+	script := ""
+	defaults := ""
+
+	switch cmd.shell {
+	case "bash", "zsh":
+		script = bashShellInit
+		if cmd.shell == "zsh" {
+			script = zshShellInit
+		}
+		if len(cmd.filters) > 0 {
+			defaults = "    if [ $# -eq 1 ] && [ \"$1\" != \".\" ]; then\n" +
+				"        set -- " + quoteArgs(cmd.filters) + " \"$1\"\n" +
+				"    fi\n"
+		}
+	case "fish":
+		script = fishShellInit
+		if len(cmd.filters) > 0 {
+			defaults = "    if test (count $argv) -eq 1; and test \"$argv[1]\" != \".\"\n" +
+				"        set argv " + quoteFishArgs(cmd.filters) + " $argv\n" +
+				"    end\n"
+		}
+	case "":
+		return cmd.Help(), nil
+	default:
+		return "", fmt.Errorf("Unknown shell \"%s\", expected bash, zsh or fish.", cmd.shell)
+	}
+
+	return strings.Replace(script, "@DEFAULTS@", defaults, 1), nil
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package config implements configuration and start-up.
+// This source generates shell completion.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"github.com/marcelfw/mgit/command"
+	"github.com/marcelfw/mgit/repository"
+	"sort"
+	"strings"
+)
+
+// The scripts ask mgit for commands, shortcuts and command flags, because these depend on the configuration
+// of the current directory. Repository names come from "mgit echo".
+
+const bashCompletion = `# mgit completion for bash, add to ~/.bashrc:
+#   source <(mgit completion bash)
+_mgit()
+{
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    # -s and -root select the repositories for -name
+    local i=1 command="" globals=()
+    while [ $i -lt $COMP_CWORD ]; do
+        case "${COMP_WORDS[i]}" in
+            -s|-root)
+                globals+=("${COMP_WORDS[i]}" "${COMP_WORDS[i+1]}")
+                i=$((i+2))
+                continue ;;
+            @VALUEFLAGS@|-name)
+                i=$((i+2))
+                continue ;;
+            -*)
+                ;;
+            *)
+                command="${COMP_WORDS[i]}"
+                break ;;
+        esac
+        i=$((i+1))
+    done
+
+    case "$prev" in
+        -s)
+            COMPREPLY=( $(compgen -W "$(mgit completion shortcuts 2>/dev/null)" -- "$cur") )
+            return ;;
+        -name)
+            COMPREPLY=( $(compgen -W "$(mgit "${globals[@]}" echo '{{ .Name }}' 2>/dev/null)" -- "$cur") )
+            return ;;
+        -root)
+            COMPREPLY=( $(compgen -d -- "$cur") )
+            return ;;
+        @VALUEFLAGS@)
+            return ;;
+    esac
+
+    if [ -z "$command" ]; then
+        if [[ "$cur" == -* ]]; then
+            COMPREPLY=( $(compgen -W "@FLAGS@" -- "$cur") )
+        else
+            COMPREPLY=( $(compgen -W "$(mgit completion commands 2>/dev/null)" -- "$cur") )
+        fi
+    elif [ "$command" == "help" ]; then
+        COMPREPLY=( $(compgen -W "$(mgit completion commands 2>/dev/null) @TOPICS@" -- "$cur") )
+    elif [[ "$cur" == -* ]]; then
+        COMPREPLY=( $(compgen -W "$(mgit completion flags "$command" 2>/dev/null)" -- "$cur") )
+    fi
+}
+complete -o default -F _mgit mgit
+`
+
+const zshCompletion = `#compdef mgit
+# mgit completion for zsh, add to ~/.zshrc after compinit:
+#   source <(mgit completion zsh)
+_mgit()
+{
+    # -s and -root select the repositories for -name
+    local i=2 command=""
+    local -a globals
+    while (( i < CURRENT )); do
+        case "${words[i]}" in
+            -s|-root)
+                globals+=("${words[i]}" "${words[i+1]}")
+                (( i += 2 ))
+                continue ;;
+            @VALUEFLAGS@|-name)
+                (( i += 2 ))
+                continue ;;
+            -*)
+                ;;
+            *)
+                command="${words[i]}"
+                break ;;
+        esac
+        (( i++ ))
+    done
+
+    case "${words[CURRENT-1]}" in
+        -s)
+            compadd -- ${(f)"$(mgit completion shortcuts 2>/dev/null)"}
+            return ;;
+        -name)
+            compadd -- ${(f)"$(mgit "${globals[@]}" echo '{{ .Name }}' 2>/dev/null)"}
+            return ;;
+        -root)
+            _directories
+            return ;;
+        @VALUEFLAGS@)
+            return ;;
+    esac
+
+    if [[ -z "$command" ]]; then
+        if [[ "$PREFIX" == -* ]]; then
+            compadd -- @FLAGS@
+        else
+            compadd -- ${(f)"$(mgit completion commands 2>/dev/null)"}
+        fi
+    elif [[ "$command" == "help" ]]; then
+        compadd -- ${(f)"$(mgit completion commands 2>/dev/null)"} @TOPICS@
+    elif [[ "$PREFIX" == -* ]]; then
+        compadd -- ${(f)"$(mgit completion flags "$command" 2>/dev/null)"}
+    else
+        _files
+    fi
+}
+compdef _mgit mgit
+`
+
+const fishCompletion = `# mgit completion for fish, save as ~/.config/fish/completions/mgit.fish:
+#   mgit completion fish > ~/.config/fish/completions/mgit.fish
+function __mgit_command
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    while set -q tokens[1]
+        switch $tokens[1]
+            case @VALUEFLAGS@ '-s' '-name' '-root'
+                set -e tokens[1]
+                set -e tokens[1]
+            case '-*'
+                set -e tokens[1]
+            case '*'
+                echo $tokens[1]
+                return 0
+        end
+    end
+    return 1
+end
+
+# -s and -root select the repositories for -name
+function __mgit_globals
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    while set -q tokens[2]
+        switch $tokens[1]
+            case '-s' '-root'
+                echo $tokens[1]
+                echo $tokens[2]
+                set -e tokens[1]
+                set -e tokens[1]
+            case @VALUEFLAGS@ '-name'
+                set -e tokens[1]
+                set -e tokens[1]
+            case '-*'
+                set -e tokens[1]
+            case '*'
+                return
+        end
+    end
+end
+
+complete -c mgit -f
+complete -c mgit -n 'not __mgit_command' -a '(mgit completion commands 2>/dev/null)'
+@OPTIONS@complete -c mgit -n '__mgit_command | string match -q help' -a '(mgit completion commands 2>/dev/null) @TOPICS@'
+complete -c mgit -n '__mgit_command >/dev/null' -a '(mgit completion flags (__mgit_command) 2>/dev/null)'
+complete -c mgit -n '__mgit_command >/dev/null' -F
+`
+
+type cmdCompletion struct {
+	action string
+	args   []string
+}
+
+func NewCompletionCommand() cmdCompletion {
+	var cmd cmdCompletion
+
+	return cmd
+}
+
+func (cmd cmdCompletion) Usage() string {
+	return "Generate shell completion (bash, zsh or fish)."
+}
+
+func (cmd cmdCompletion) Help() string {
+	return `Generate shell completion.
+
+  bash   Add "source <(mgit completion bash)" to ~/.bashrc
+  zsh    Add "source <(mgit completion zsh)" to ~/.zshrc (after compinit)
+  fish   Run "mgit completion fish > ~/.config/fish/completions/mgit.fish"
+
+Flags, commands (including configured commands), shortcuts and repository
+names for -name are completed.`
+}
+
+func (cmd cmdCompletion) Init(args []string, interactive bool) (outCmd repository.Command) {
+	if len(args) >= 1 {
+		cmd.action = args[0]
+		cmd.args = args[1:]
+	}
+	return cmd
+}
+
+func (cmd cmdCompletion) Output(commands map[string]repository.Command, version string) string {
+	output, _ := cmd.OutputError(commands, version)
+	return output
+}
+
+// OutputError returns the completion, with an error for an unknown shell.
+func (cmd cmdCompletion) OutputError(commands map[string]repository.Command, version string) (string, error) {
+	mgitFlags, _, _ := newFlagSet(GetFilterDefs())
+
+	switch cmd.action {
+	case "bash":
+		return completionScript(bashCompletion, mgitFlags, "%s", "|"), nil
+	case "zsh":
+		return completionScript(zshCompletion, mgitFlags, "%s", "|"), nil
+	case "fish":
+		return completionScript(fishCompletion, mgitFlags, "'%s'", " "), nil
+	case "commands":
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, "\n"), nil
+	case "shortcuts":
+		return strings.Join(shortcutNames(), "\n"), nil
+	case "flags":
+		if len(cmd.args) == 1 {
+			if flagCommand, ok := commands[cmd.args[0]].(repository.FlagCommand); ok {
+				commandFlags := flag.NewFlagSet(cmd.args[0], flag.ContinueOnError)
+				flagCommand.AddFlags(commandFlags)
+				names := make([]string, 0, 5)
+				commandFlags.VisitAll(func(commandFlag *flag.Flag) {
+					names = append(names, "-"+commandFlag.Name)
+				})
+				return strings.Join(names, "\n"), nil
+			}
+		}
+		return "", nil
+	case "":
+		return cmd.Help(), nil
+	}
+	return "", fmt.Errorf("Unknown shell \"%s\", expected bash, zsh or fish.", cmd.action)
+}
+
+// takesValue returns true if the flag needs a value.
+func takesValue(mgitFlag *flag.Flag) bool {
+	if boolFlag, ok := mgitFlag.Value.(interface {
+		IsBoolFlag() bool
+	}); ok {
+		return !boolFlag.IsBoolFlag()
+	}
+	return true
+}
+
+// completionScript fills in the flags of mgit in the script.
+// Flags which take a value are formatted with format and joined with separator to match them in the script.
+func completionScript(script string, mgitFlags *flag.FlagSet, format, separator string) string {
+	names := make([]string, 0, 50)
+	valueNames := make([]string, 0, 50)
+	options := ""
+
+	mgitFlags.VisitAll(func(mgitFlag *flag.Flag) {
+		names = append(names, "-"+mgitFlag.Name)
+
+		option := "complete -c mgit -n 'not __mgit_command' -o " + mgitFlag.Name
+		switch {
+		case mgitFlag.Name == "s":
+			option += " -x -a '(mgit completion shortcuts 2>/dev/null)'"
+		case mgitFlag.Name == "name":
+			option += " -x -a '(mgit (__mgit_globals) echo \"{{ .Name }}\" 2>/dev/null)'"
+		case mgitFlag.Name == "root":
+			option += " -x -a '(__fish_complete_directories)'"
+		case takesValue(mgitFlag):
+			option += " -x"
+		}
+		options += option + " -d '" + strings.Replace(mgitFlag.Usage, "'", "\\'", -1) + "'\n"
+
+		if takesValue(mgitFlag) && mgitFlag.Name != "s" && mgitFlag.Name != "name" && mgitFlag.Name != "root" {
+			valueNames = append(valueNames, fmt.Sprintf(format, "-"+mgitFlag.Name))
+		}
+	})
+
+	return strings.NewReplacer(
+		"@FLAGS@", strings.Join(names, " "),
+		"@VALUEFLAGS@", strings.Join(valueNames, separator),
+		"@TOPICS@", strings.Join(command.HelpTopics(), " "),
+		"@OPTIONS@", options,
+	).Replace(strings.TrimRight(script, "\n"))
+}
+
+// shortcutNames returns the names of all shortcuts.
+func shortcutNames() []string {
+	names := make([]string, 0, 10)
+	seen := make(map[string]bool)
+
+	for _, configs := range []configFiles{parentConfigs, globalConfigs} {
+		for _, config := range configs {
+			for section := range config.config {
+				if match := shortcutRegexp.FindStringSubmatch(section); len(match) >= 2 && !seen[match[1]] {
+					seen[match[1]] = true
+					names = append(names, match[1])
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
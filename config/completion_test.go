@@ -0,0 +1,54 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package config
+
+import (
+	"github.com/marcelfw/mgit/repository"
+	go_ini "github.com/vaughan0/go-ini"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		script := NewCompletionCommand().Init([]string{shell}, false).(cmdCompletion).Output(GetCommands(), "")
+		if placeholder := regexp.MustCompile("@[A-Z]+@").FindString(script); placeholder != "" {
+			t.Errorf("Expected all placeholders of %s to be replaced, found %s", shell, placeholder)
+		}
+		if !strings.Contains(script, "-remoteurl") || !strings.Contains(script, "-depth") {
+			t.Errorf("Expected %s script to complete filters and flags", shell)
+		}
+	}
+}
+
+func TestUnknownShell(t *testing.T) {
+	commands := GetCommands()
+	for _, name := range []string{"completion", "shell-init"} {
+		infoCommand := commands[name].Init([]string{"tcsh"}, false).(repository.InfoErrorCommand)
+		if output, err := infoCommand.OutputError(commands, ""); err == nil || output != "" {
+			t.Errorf("Expected %s to fail for an unknown shell, got '%s'", name, output)
+		}
+		infoCommand = commands[name].Init(nil, false).(repository.InfoErrorCommand)
+		if output, err := infoCommand.OutputError(commands, ""); err != nil || output != commands[name].Help() {
+			t.Errorf("Expected %s without a shell to show the help, got %v", name, err)
+		}
+	}
+}
+
+func TestShortcutNames(t *testing.T) {
+	savedParents, savedGlobals := parentConfigs, globalConfigs
+	defer func() { parentConfigs, globalConfigs = savedParents, savedGlobals }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{"shortcut \"work\"": {}, "local": {}}, nil},
+	}
+	globalConfigs = configFiles{
+		{"/home/.mgit", go_ini.File{"shortcut \"work\"": {}, "shortcut \"all\"": {}}, nil},
+	}
+
+	if names, expected := shortcutNames(), []string{"all", "work"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected shortcuts '%v', got '%v'", expected, names)
+	}
+}
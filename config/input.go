@@ -8,6 +8,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/marcelfw/mgit/command"
+	"github.com/marcelfw/mgit/engine"
 	"github.com/marcelfw/mgit/repository"
 	go_ini "github.com/vaughan0/go-ini"
 	"io/ioutil"
@@ -15,13 +16,16 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 type configFile struct {
 	file   string
 	config go_ini.File
+	lines  map[string]int // line of every section and key
 }
 
 type configFiles []configFile
@@ -29,33 +33,41 @@ type configFiles []configFile
 var localRegexp *regexp.Regexp
 var shortcutRegexp *regexp.Regexp
 var commandRegexp *regexp.Regexp
+var repoRegexp *regexp.Regexp
+var groupRegexp *regexp.Regexp
+var variableRegexp *regexp.Regexp
 
 var globalConfigs configFiles
 var parentConfigs configFiles
 
+// configuration files which could not be read
+var loadProblems []configProblem
+
 // init
 func init() {
 	localRegexp = regexp.MustCompile("^local$")
-	shortcutRegexp = regexp.MustCompile("shortcut \"(.+)\"")
-	commandRegexp = regexp.MustCompile("command \"(.+)\"")
+	shortcutRegexp = regexp.MustCompile("^shortcut \"(.+)\"$")
+	commandRegexp = regexp.MustCompile("^command \"(.+)\"$")
+	repoRegexp = regexp.MustCompile("^repo \"(.+)\"$")
+	groupRegexp = regexp.MustCompile("^group \"(.+)\"$")
+	variableRegexp = regexp.MustCompile(`\$\$|\$\{[A-Za-z_][A-Za-z0-9_]*\}|\$[A-Za-z_][A-Za-z0-9_]*`)
 
 	readConfigs()
+	readLabelsFromConfiguration()
 }
 
 // readConfigs finds all configuration files and loads them
 func readConfigs() {
 	globalConfigs = make([]configFile, 0, 10)
 	parentConfigs = make([]configFile, 0, 10)
+	loadProblems = make([]configProblem, 0)
+
+	loaded := make(map[string]bool)
 
 	// Follow parent directories and add all configurations.
 	if wd, err := os.Getwd(); err == nil {
 		for {
-			filename := wd + "/.mgit"
-			if fi, err := os.Stat(filename); err == nil && !fi.IsDir() {
-				if config, err := go_ini.LoadFile(filename); err == nil {
-					parentConfigs = append(parentConfigs, configFile{filename, config})
-				}
-			}
+			parentConfigs = loadConfig(wd+"/.mgit", parentConfigs, loaded)
 
 			nwd := path.Dir(wd)
 			if nwd == wd || nwd == "." {
@@ -66,22 +78,163 @@ func readConfigs() {
 		}
 	}
 
+	// Add configuration from the environment.
+	for _, filename := range filepath.SplitList(os.Getenv("MGIT_CONFIG")) {
+		if filename != "" {
+			globalConfigs = loadConfig(expandHome(filename), globalConfigs, loaded)
+		}
+	}
+
 	// Add configuration from user' directory.
-	if user, err := user.Current(); err == nil {
-		filename := user.HomeDir + "/.mgit"
-		if fi, err := os.Stat(filename); err == nil && !fi.IsDir() {
-			if config, err := go_ini.LoadFile(filename); err == nil {
-				globalConfigs = append(globalConfigs, configFile{filename, config})
+	if home := homeDir(); home != "" {
+		globalConfigs = loadConfig(home+"/.mgit", globalConfigs, loaded)
+
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = home + "/.config"
+		}
+		globalConfigs = loadConfig(configHome+"/mgit/config", globalConfigs, loaded)
+	}
+	globalConfigs = loadConfig("/etc/mgit", globalConfigs, loaded)
+}
+
+// loadConfig adds the configuration file and the files it includes to configs.
+// Included files follow the file which includes them, so the including file takes precedence.
+func loadConfig(filename string, configs configFiles, loaded map[string]bool) configFiles {
+	if loaded[filename] {
+		return configs
+	}
+	fi, err := os.Stat(filename)
+	if err != nil || fi.IsDir() {
+		return configs
+	}
+	loaded[filename] = true
+	config, err := go_ini.LoadFile(filename)
+	if err != nil {
+		problem := configProblem{file: filename, message: err.Error(), fatal: true}
+		if syntaxErr, ok := err.(go_ini.ErrSyntax); ok {
+			problem.line = syntaxErr.Line
+			problem.message = "invalid syntax \"" + syntaxErr.Source + "\""
+		}
+		loadProblems = append(loadProblems, problem)
+		return configs
+	}
+
+	expandEnvironment(config)
+	configs = append(configs, configFile{filename, config, readLineNumbers(filename)})
+
+	if value, ok := config.Get("include", "path"); ok {
+		for _, pattern := range repository.SplitList(value) {
+			pattern = expandHome(pattern)
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(filename), pattern)
+			}
+			files, err := filepath.Glob(pattern)
+			if err != nil {
+				loadProblems = append(loadProblems, configProblem{file: filename, line: configs[len(configs)-1].line("include", "path"),
+					section: "include", key: "path", message: fmt.Sprintf("invalid include \"%s\": %v", pattern, err), fatal: true})
+				continue
+			}
+			for _, file := range files {
+				log.Printf("including \"%s\" from \"%s\"", file, filename)
+				configs = loadConfig(file, configs, loaded)
 			}
 		}
 	}
-	if fi, err := os.Stat("/etc/mgit"); err == nil && !fi.IsDir() {
-		if config, err := go_ini.LoadFile("/etc/mgit"); err == nil {
-			globalConfigs = append(globalConfigs, configFile{"/etc/mgit", config})
+
+	return configs
+}
+
+// lineKey returns the key to find the line of a section or key.
+func lineKey(section, key string) string {
+	return section + "\x00" + key
+}
+
+// readLineNumbers returns the line of every section and key, reading the file like go-ini does.
+// For keys which are repeated the last line is returned, because that value is used.
+func readLineNumbers(filename string) map[string]int {
+	lines := make(map[string]int)
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return lines
+	}
+
+	section := ""
+	for idx, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+		case strings.Contains(line, "="):
+			lines[lineKey(section, strings.TrimSpace(line[:strings.Index(line, "=")]))] = idx + 1
+		case line[0] == '[' && line[len(line)-1] == ']':
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := lines[lineKey(section, "")]; !ok {
+				lines[lineKey(section, "")] = idx + 1
+			}
+		}
+	}
+
+	return lines
+}
+
+// line returns the line of the key in the section, or the line of the section if the key is not known.
+func (config configFile) line(section, key string) int {
+	if line, ok := config.lines[lineKey(section, key)]; ok {
+		return line
+	}
+	return config.lines[lineKey(section, "")]
+}
+
+// homeDir returns the home directory of the user, empty if unknown.
+func homeDir() string {
+	if user, err := user.Current(); err == nil {
+		return user.HomeDir
+	}
+	return os.Getenv("HOME")
+}
+
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(filename string) string {
+	if filename == "~" || strings.HasPrefix(filename, "~/") {
+		return homeDir() + filename[1:]
+	}
+	return filename
+}
+
+// expandEnvironment replaces $VAR and ${VAR} in all values except scripts, $$ becomes $.
+// Variables which are not set are left alone.
+func expandEnvironment(config go_ini.File) {
+	for section, vars := range config {
+		for key, value := range vars {
+			if !isScriptValue(section, key) {
+				vars[key] = expandVariables(value)
+			}
 		}
 	}
 }
 
+// isScriptValue returns true if the value is run by a shell or program, which expands variables itself.
+func isScriptValue(section, key string) bool {
+	if commandRegexp.MatchString(section) {
+		return command.IsScriptKey(key)
+	}
+	return key == "if" && (shortcutRegexp.MatchString(section) || localRegexp.MatchString(section))
+}
+
+func expandVariables(value string) string {
+	return variableRegexp.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		name := strings.Trim(match, "${}")
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return match
+	})
+}
+
 func reduceConfigs(regexp regexp.Regexp, reduceFunc func(string, []string, map[string]string), configArrays ...configFiles) {
 	for _, configs := range configArrays {
 		for _, config := range configs {
@@ -101,78 +254,340 @@ func reduceConfigs(regexp regexp.Regexp, reduceFunc func(string, []string, map[s
 	}
 }
 
+// readSection returns the first section of the type with the name and the file it is read from.
+// Shortcuts, commands and the local section are all read this way, name is empty for the local section.
+func readSection(sectionType regexp.Regexp, name string) (map[string]string, string, bool) {
+	var vars map[string]string
+	var file string
+
+	var mapFunc = func(sectionFile string, match []string, sectionVars map[string]string) {
+		if vars == nil && (name == "" || (len(match) >= 2 && match[1] == name)) {
+			vars, file = sectionVars, sectionFile
+		}
+	}
+
+	reduceConfigs(sectionType, mapFunc, parentConfigs, globalConfigs)
+
+	return vars, file, vars != nil
+}
+
 // readShortcutFromConfiguration reads the configuration and return the filter for the shortcut.
 // return bool false if something went wrong.
 func readShortcutFromConfiguration(shortcut string) (map[string]string, bool) {
-	var filterMap map[string]string
+	filterMap, file, ok := readSection(*shortcutRegexp, shortcut)
+	if ok {
+		log.Printf("reading shortcut \"%s\" from \"%s\"", shortcut, file)
+	}
+
+	return filterMap, ok
+}
+
+// resolveShortcut returns the filter for the shortcut including the shortcuts it extends.
+// Values of the shortcut override the values of the shortcuts it extends.
+func resolveShortcut(shortcut string, visiting []string) (map[string]string, error) {
+	values, err := resolveShortcutValues(shortcut, visiting)
+	if err != nil {
+		return nil, err
+	}
+
+	filterMap := make(map[string]string)
+	for key, value := range values {
+		filterMap[key] = value.value
+	}
+	return filterMap, nil
+}
+
+// resolveShortcutValues is resolveShortcut which also returns the file each value is read from.
+func resolveShortcutValues(shortcut string, visiting []string) (map[string]configValue, error) {
+	for idx, name := range visiting {
+		if name == shortcut {
+			return nil, fmt.Errorf("Shortcut \"%s\" extends itself (%s).", shortcut, strings.Join(append(visiting[idx:], shortcut), " -> "))
+		}
+	}
+
+	vars, file, ok := readSection(*shortcutRegexp, shortcut)
+	if !ok {
+		return nil, fmt.Errorf("Shortcut \"%s\" not found.", shortcut)
+	}
+
+	values := make(map[string]configValue)
+	if value, ok := vars["extends"]; ok {
+		for _, parent := range repository.SplitList(value) {
+			parentValues, err := resolveShortcutValues(parent, append(visiting, shortcut))
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range parentValues {
+				values[key] = value
+			}
+		}
+	}
+	for key, value := range vars {
+		if key != "extends" {
+			values[key] = configValue{key, value, file}
+		}
+	}
+
+	return values, nil
+}
 
-	var mapFunc = func(file string, match []string, vars map[string]string) {
-		if len(match) >= 2 && match[1] == shortcut {
-			if filterMap == nil {
-				log.Printf("reading shortcut \"%s\" from \"%s\"", shortcut, file)
-				filterMap = vars
+// combineShortcuts returns the combined filter of the shortcuts, later shortcuts override earlier ones.
+func combineShortcuts(shortcuts []string) (map[string]string, error) {
+	filterMap := make(map[string]string)
+	for _, shortcut := range shortcuts {
+		shortcutMap, err := resolveShortcut(shortcut, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range shortcutMap {
+			filterMap[key] = value
+		}
+	}
+	return filterMap, nil
+}
+
+// stringList is a flag value which can be given multiple times.
+type stringList []string
+
+func (list *stringList) String() string {
+	if list == nil {
+		return ""
+	}
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
+// readLabelsFromConfiguration reads all repo and group sections and configures the labels.
+// Unlike shortcuts all sections are combined.
+func readLabelsFromConfiguration() {
+	rules := make([]repository.LabelRule, 0, 10)
+	groups := make(map[string][]string)
+
+	var repoFunc = func(file string, match []string, vars map[string]string) {
+		if len(match) >= 2 {
+			if value, ok := vars["labels"]; ok {
+				rules = append(rules, repository.LabelRule{Pattern: match[1], Labels: repository.SplitList(value)})
+			}
+		}
+	}
+	var groupFunc = func(file string, match []string, vars map[string]string) {
+		if len(match) >= 2 {
+			if value, ok := vars["members"]; ok {
+				groups[match[1]] = append(groups[match[1]], repository.SplitList(value)...)
+			}
+		}
+	}
+
+	reduceConfigs(*repoRegexp, repoFunc, parentConfigs, globalConfigs)
+	reduceConfigs(*groupRegexp, groupFunc, parentConfigs, globalConfigs)
+
+	repository.SetLabelRules(rules)
+	repository.SetGroups(groups)
+}
+
+// readCommandSection returns the first configuration section of the command, nil if there is none.
+func readCommandSection(name string) map[string]string {
+	vars, _, _ := readSection(*commandRegexp, name)
+
+	return vars
+}
+
+// readCommandFilters reads the default filters of a configured command.
+// Filters are written as "filters = branch = develop, if = \"test -f a,b\"", see parseFilters.
+func readCommandFilters(name string) map[string]string {
+	filterMap := make(map[string]string)
+
+	if value, ok := readCommandSection(name)["filters"]; ok {
+		filterMap = parseFilters(value)
+		log.Printf("using filters %v of command \"%s\"", filterMap, name)
+	}
+
+	return filterMap
+}
+
+// parseFilters parses "branch = develop, name = shop", a filter without value is set to true.
+// Values can be quoted with " or ' to contain a comma.
+func parseFilters(value string) map[string]string {
+	filterMap := make(map[string]string)
+	for _, filter := range splitFilters(value) {
+		parts := strings.SplitN(filter, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			continue
+		}
+		if len(parts) == 2 {
+			filterMap[key] = unquote(strings.TrimSpace(parts[1]))
+		} else {
+			filterMap[key] = "true"
+		}
+	}
+	return filterMap
+}
+
+// splitFilters splits the filters on commas which are not quoted.
+func splitFilters(value string) []string {
+	filters := make([]string, 0, 5)
+
+	var quote rune
+	start := 0
+	for idx, char := range value {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
 			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == ',':
+			filters = append(filters, value[start:idx])
+			start = idx + 1
 		}
 	}
 
-	reduceConfigs(*shortcutRegexp, mapFunc, parentConfigs, globalConfigs)
+	return append(filters, value[start:])
+}
 
-	return filterMap, filterMap != nil
+// unquote removes the quotes around a value.
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
 }
 
 // readLocalConfiguration reads the configuration and return the first "local" section it finds.
 // return bool false if something went wrong.
 func readLocalConfiguration() (map[string]string, bool) {
-	var filterMap map[string]string
+	filterMap, _, ok := readSection(*localRegexp, "")
+
+	return filterMap, ok
+}
 
-	var mapFunc = func(file string, match []string, vars map[string]string) {
-		if len(match) >= 1 {
-			if filterMap == nil {
-				filterMap = vars
+// mainFlags are the flags of mgit itself.
+type mainFlags struct {
+	rootDirectory string
+	depth         int
+	shortcuts     stringList
+	interactive   bool
+	debug         bool
+	options       engine.Options
+}
+
+// environmentFlags are the environment variables which set flags of mgit itself.
+// Filters can't be set this way, because commands run by mgit get MGIT_NAME, MGIT_PATH, ...
+// -y can't be set either, confirmation must be skipped on purpose.
+var environmentFlags = []struct {
+	variable string
+	flag     string
+}{
+	{"MGIT_SHORTCUT", "s"},
+	{"MGIT_ROOT", "root"},
+	{"MGIT_DEPTH", "depth"},
+	{"MGIT_DEBUG", "debug"},
+	{"MGIT_INTERACTIVE", "i"},
+	{"MGIT_DRYRUN", "dryrun"},
+	{"MGIT_FORMAT", "template"},
+	{"MGIT_SORT", "sort"},
+	{"MGIT_REVERSE", "reverse"},
+	{"MGIT_JOBS", "jobs"},
+}
+
+// readEnvironmentFlags sets flags not given on the command-line from the environment.
+// It returns the names of the flags which are set now.
+// The environment is ignored when MGIT_NAME is set, because then mgit is run by a command of mgit
+// and MGIT_ROOT is the root of the parent.
+func readEnvironmentFlags(mgitFlags *flag.FlagSet) (map[string]bool, error) {
+	setFlags := make(map[string]bool)
+	mgitFlags.Visit(func(mgitFlag *flag.Flag) {
+		setFlags[mgitFlag.Name] = true
+	})
+	// -n and -dryrun are the same flag
+	if setFlags["n"] {
+		setFlags["dryrun"] = true
+	}
+
+	if _, ok := os.LookupEnv("MGIT_NAME"); ok {
+		return setFlags, nil
+	}
+
+	for _, env := range environmentFlags {
+		value := os.Getenv(env.variable)
+		if value == "" || setFlags[env.flag] {
+			continue
+		}
+
+		values := []string{value}
+		if env.flag == "s" {
+			values = repository.SplitList(value)
+		}
+		for _, value := range values {
+			if err := mgitFlags.Set(env.flag, value); err != nil {
+				return setFlags, fmt.Errorf("Invalid value \"%s\" for %s: %v", value, env.variable, err)
 			}
 		}
+		setFlags[env.flag] = true
 	}
 
-	reduceConfigs(*localRegexp, mapFunc, parentConfigs, globalConfigs)
-
-	return filterMap, filterMap != nil
+	return setFlags, nil
 }
 
-// ParseCommandline parses and validates the command-line and return useful structs to continue.
-func ParseCommandline(osArgs []string, filterDefs []repository.FilterDefinition) (command string, cmdInteractive bool, args []string, repositoryFilter repository.RepositoryFilter, ok bool) {
-	var rootDirectory string
-	var depth int
-	var shortcut string
-	var interactive bool
-	var debug bool
+// newFlagSet returns the flags of mgit with the flags of all filters.
+func newFlagSet(filterDefs []repository.FilterDefinition) (*flag.FlagSet, *mainFlags, []repository.Filter) {
+	var settings mainFlags
 
 	mgitFlags := flag.NewFlagSet("mgitFlags", flag.ContinueOnError)
 
 	// These are truly hard-coded for now.
-	mgitFlags.StringVar(&shortcut, "s", "", "read settings with name from configuration file")
-	mgitFlags.StringVar(&rootDirectory, "root", "", "set root directory")
-	mgitFlags.IntVar(&depth, "depth", 0, "maximum depth to search in")
-	mgitFlags.BoolVar(&interactive, "i", false, "run command interactively")
-	mgitFlags.BoolVar(&debug, "debug", false, "show debug log")
+	mgitFlags.Var(&settings.shortcuts, "s", "read settings with name from configuration file (repeat to combine)")
+	mgitFlags.StringVar(&settings.rootDirectory, "root", "", "set root directory")
+	mgitFlags.IntVar(&settings.depth, "depth", 0, "maximum depth to search in")
+	mgitFlags.BoolVar(&settings.interactive, "i", false, "run command interactively")
+	mgitFlags.BoolVar(&settings.debug, "debug", false, "show debug log")
+	mgitFlags.BoolVar(&settings.options.DryRun, "n", false, "show what would run")
+	mgitFlags.BoolVar(&settings.options.DryRun, "dryrun", false, "show what would run")
+	mgitFlags.BoolVar(&settings.options.Yes, "y", false, "assume yes when asked for confirmation")
+	mgitFlags.StringVar(&settings.options.Template, "template", "", "render output with template")
+	mgitFlags.StringVar(&settings.options.Sort, "sort", "", "sort output on column")
+	mgitFlags.BoolVar(&settings.options.Reverse, "reverse", false, "reverse sort order")
+	mgitFlags.IntVar(&settings.options.Jobs, "jobs", 0, "number of repositories to run at the same time")
 
 	filters := make([]repository.Filter, 0, len(filterDefs))
 	for _, filterDef := range filterDefs {
 		filters = append(filters, filterDef.AddFlags(mgitFlags))
 	}
 
-	mgitFlags.Parse(osArgs)
+	return mgitFlags, &settings, filters
+}
+
+// ParseCommandline parses and validates the command-line and return useful structs to continue.
+func ParseCommandline(osArgs []string, filterDefs []repository.FilterDefinition) (command string, cmdInteractive bool, args []string, repositoryFilter repository.RepositoryFilter, options engine.Options, ok bool) {
+	mgitFlags, settings, filters := newFlagSet(filterDefs)
+
+	if err := mgitFlags.Parse(osArgs); err != nil {
+		return command, false, args, repositoryFilter, settings.options, false
+	}
+
+	// Flags on the command-line go before the environment, which goes before the configuration.
+	setFlags, err := readEnvironmentFlags(mgitFlags)
+	if err != nil {
+		fmt.Println(err)
+		return command, false, args, repositoryFilter, settings.options, false
+	}
 
-	if !debug {
+	if !settings.debug {
 		log.SetOutput(ioutil.Discard)
 	}
 
 	var filterMap map[string]string
 
-	if shortcut != "" {
-		filterMap, ok = readShortcutFromConfiguration(shortcut)
-		if !ok {
-			fmt.Printf("Shortcut \"%s\" not found.\n", shortcut)
-			return command, false, args, repositoryFilter, false
+	if len(settings.shortcuts) > 0 {
+		if filterMap, err = combineShortcuts(settings.shortcuts); err != nil {
+			fmt.Println(err)
+			return command, false, args, repositoryFilter, settings.options, false
 		}
 	} else {
 		filterMap, ok = readLocalConfiguration()
@@ -183,65 +598,131 @@ func ParseCommandline(osArgs []string, filterDefs []repository.FilterDefinition)
 
 	if mgitFlags.NArg() == 0 {
 		fmt.Print("Could not find command to execute.\n")
-		return command, false, args, repositoryFilter, false
+		return command, false, args, repositoryFilter, settings.options, false
 	}
 
-	mgitFlags.VisitAll(func(flag *flag.Flag) {
-		if value, ok := filterMap[flag.Name]; ok {
-			if flag.Value.String() == "" {
-				flag.Value.Set(value)
+	// Stop on problems in the configuration used for this run, except for commands which only show
+	// information (like "config" to find the problems).
+	if _, ok := GetCommands()[mgitFlags.Arg(0)].(repository.InfoCommand); !ok {
+		if !checkUsedSections(filterDefs, usedSections(settings.shortcuts, mgitFlags.Arg(0))) {
+			return command, false, args, repositoryFilter, settings.options, false
+		}
+	}
+
+	// Default filters of the command go before the shortcut or local configuration.
+	commandFilters := readCommandFilters(mgitFlags.Arg(0))
+	for _, key := range sortedKeys(commandFilters) {
+		if key == "s" || mgitFlags.Lookup(key) == nil {
+			fmt.Printf("Unknown filter \"%s\" in filters of command \"%s\".\n", key, mgitFlags.Arg(0))
+			return command, false, args, repositoryFilter, settings.options, false
+		}
+	}
+
+	// Only flags not given on the command-line or in the environment are taken from the configuration.
+	filtersOk := true
+	mgitFlags.VisitAll(func(mgitFlag *flag.Flag) {
+		if !setFlags[mgitFlag.Name] {
+			if value, ok := commandFilters[mgitFlag.Name]; ok {
+				if err := mgitFlag.Value.Set(value); err != nil {
+					fmt.Printf("Invalid value \"%s\" for filter %s of command \"%s\": %v\n", value, mgitFlag.Name, mgitFlags.Arg(0), err)
+					filtersOk = false
+				}
+			} else if value, ok := filterMap[mgitFlag.Name]; ok {
+				mgitFlag.Value.Set(value)
 			}
 		}
 
-		if flag.Value.String() != "" {
-			log.Printf("Using flag \"%s\" with value \"%s\"", flag.Name, flag.Value.String())
+		if mgitFlag.Value.String() != "" {
+			log.Printf("Using flag \"%s\" with value \"%s\"", mgitFlag.Name, mgitFlag.Value.String())
 		}
 	})
+	if !filtersOk {
+		return command, false, args, repositoryFilter, settings.options, false
+	}
 
-	if rootDirectory == "" {
+	if settings.rootDirectory == "" {
 		if value, ok := filterMap["root"]; ok {
-			rootDirectory = value
+			settings.rootDirectory = value
 		}
-		if rootDirectory == "" {
-			rootDirectory = "."
+		if settings.rootDirectory == "" {
+			settings.rootDirectory = "."
 		}
 	}
-	if depth == 0 {
+	if settings.depth == 0 {
 		if value, ok := filterMap["depth"]; ok {
 			if ivalue, err := strconv.ParseInt(value, 10, 0); err == nil {
-				depth = int(ivalue)
+				settings.depth = int(ivalue)
 			}
 		}
 	}
-	if interactive {
+	if settings.interactive {
 		cmdInteractive = true
 	}
 
-	log.Printf("Using root directory and depth \"%s\", \"%d\"", rootDirectory, depth)
+	log.Printf("Using root directory and depth \"%s\", \"%d\"", settings.rootDirectory, settings.depth)
 
-	repositoryFilter = repository.NewRepositoryFilter(rootDirectory, depth, filters)
+	repositoryFilter = repository.NewRepositoryFilter(settings.rootDirectory, settings.depth, filters)
 
 	args = mgitFlags.Args()
 	command = args[0]
 	args = args[1:]
 
-	return command, cmdInteractive, args, repositoryFilter, true
+	return command, cmdInteractive, args, repositoryFilter, settings.options, true
+}
+
+// ParseCommandFlags parses the flags of a command, given after the command name.
+// Flags which are not given are read from the configuration section of the command.
+// return bool false if something went wrong.
+func ParseCommandFlags(name string, flagCommand repository.FlagCommand, args []string) (repository.Command, []string, bool) {
+	commandFlags := flag.NewFlagSet(name, flag.ContinueOnError)
+	cmd := flagCommand.AddFlags(commandFlags)
+
+	if err := commandFlags.Parse(args); err != nil {
+		return cmd, args, false
+	}
+
+	setFlags := make(map[string]bool)
+	commandFlags.Visit(func(commandFlag *flag.Flag) {
+		setFlags[commandFlag.Name] = true
+	})
+
+	vars := readCommandSection(name)
+	ok := true
+	commandFlags.VisitAll(func(commandFlag *flag.Flag) {
+		if value, found := vars[commandFlag.Name]; found && !setFlags[commandFlag.Name] {
+			if err := commandFlag.Value.Set(value); err != nil {
+				fmt.Printf("Invalid value \"%s\" for %s of command \"%s\": %v\n", value, commandFlag.Name, name, err)
+				ok = false
+			}
+		}
+	})
+
+	return cmd, commandFlags.Args(), ok
 }
 
 // createCommand creates a command based on a configuration section.
 // returns _, false if command could not be created
 func createCommand(vars map[string]string) (repository.Command, bool) {
-	if value, ok := vars["git"]; ok {
-		// add Git command
-		return command.NewGitProxyCommand(value, vars), true
+	switch command.ConfigKind(vars) {
+	case "steps":
+		return command.NewPipelineCommand(vars), true
+	case "git":
+		return command.NewGitProxyCommand(vars["git"], vars), true
+	case "exec":
+		return command.NewConfigExecCommand(vars["exec"], false, vars), true
+	case "shell":
+		return command.NewConfigExecCommand(vars["shell"], true, vars), true
 	}
 	return nil, false
 }
 
 // AddConfigCommands add commands from the configuration files to the command list.
+// Like shortcuts only the first section with the same name is used, see readCommandSection.
 func AddConfigCommands(commands map[string]repository.Command) map[string]repository.Command {
+	seen := make(map[string]bool)
 	var cmdFunc = func(file string, match []string, vars map[string]string) {
-		if len(match) >= 2 {
+		if len(match) >= 2 && !seen[match[1]] {
+			seen[match[1]] = true
 			if command, ok := createCommand(vars); ok {
 				commands[match[1]] = command
 			}
@@ -4,6 +4,9 @@ package config
 
 import (
 	"github.com/marcelfw/mgit/repository"
+	go_ini "github.com/vaughan0/go-ini"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -11,12 +14,12 @@ import (
 func TestHardcodedParseCommandLine(t *testing.T) {
 	filters := make([]repository.FilterDefinition, 0)
 
-	_, _, _, _, ok := ParseCommandline(make([]string, 0), filters)
+	_, _, _, _, _, ok := ParseCommandline(make([]string, 0), filters)
 	if ok {
 		t.Error("Empty command-line should not parse succesfully.")
 	}
 
-	command, _, _, repFilter, ok := ParseCommandline([]string{"list"}, filters)
+	command, _, _, repFilter, _, ok := ParseCommandline([]string{"list"}, filters)
 	if !ok {
 		t.Errorf("Expected ok to be true, but got '%v'", ok)
 	}
@@ -28,7 +31,7 @@ func TestHardcodedParseCommandLine(t *testing.T) {
 		t.Errorf("Expected rootDirectory to be '.', got '%v'", value)
 	}
 
-	command, _, _, repFilter, ok = ParseCommandline([]string{"-root", "/", "status"}, filters)
+	command, _, _, repFilter, _, ok = ParseCommandline([]string{"-root", "/", "status"}, filters)
 	if !ok {
 		t.Errorf("Expected ok to be true, but got %v", ok)
 	}
@@ -40,7 +43,7 @@ func TestHardcodedParseCommandLine(t *testing.T) {
 		t.Errorf("Expected rootDirectory to be '/', got '%v'", value)
 	}
 
-	command, _, _, repFilter, ok = ParseCommandline([]string{"-depth", "10", "path"}, filters)
+	command, _, _, repFilter, _, ok = ParseCommandline([]string{"-depth", "10", "path"}, filters)
 	if !ok {
 		t.Errorf("Expected ok to be true, but got %v", ok)
 	}
@@ -52,7 +55,7 @@ func TestHardcodedParseCommandLine(t *testing.T) {
 		t.Errorf("Expected depth to be '10', got '%v'", value)
 	}
 
-	command, _, _, repFilter, ok = ParseCommandline([]string{"-root", ".", "status"}, filters)
+	command, _, _, repFilter, _, ok = ParseCommandline([]string{"-root", ".", "status"}, filters)
 	if !ok {
 		t.Errorf("Expected ok to be true, but got %v", ok)
 	}
@@ -64,3 +67,210 @@ func TestHardcodedParseCommandLine(t *testing.T) {
 		t.Errorf("Expected rootDirectory to be '.', got '%v'", value)
 	}
 }
+
+func TestReadCommandFilters(t *testing.T) {
+	savedConfigs := parentConfigs
+	defer func() { parentConfigs = savedConfigs }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{
+			"command \"lg\"": {"git": "log", "filters": "branch = develop, name=shop ,if = \"test -f a,b\", author='x, y', bare"},
+		}, nil},
+	}
+
+	filterMap := readCommandFilters("lg")
+	expected := map[string]string{"branch": "develop", "name": "shop", "if": "test -f a,b", "author": "x, y", "bare": "true"}
+	if !reflect.DeepEqual(filterMap, expected) {
+		t.Errorf("Expected filters to be '%v', got '%v'", expected, filterMap)
+	}
+
+	if filterMap := readCommandFilters("status"); len(filterMap) != 0 {
+		t.Errorf("Expected no filters, got '%v'", filterMap)
+	}
+}
+
+func TestConfigCommandSection(t *testing.T) {
+	savedParents, savedGlobals := parentConfigs, globalConfigs
+	defer func() { parentConfigs, globalConfigs = savedParents, savedGlobals }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{
+			"command \"x\"":     {"git": "status", "filters": "name = a"},
+			"command \"dirty\"": {"git": "status", "filters": "dirty = true"},
+		}, nil},
+	}
+	globalConfigs = configFiles{
+		{"/home/.mgit", go_ini.File{
+			"command \"x\"": {"git": "log", "args": "--oneline", "filters": "name = b"},
+		}, nil},
+	}
+
+	// the command and its filters come from the same (first) section
+	if usage := AddConfigCommands(builtinCommands())["x"].Usage(); usage != "Run \"git status\"." {
+		t.Errorf("Expected the command of the first section, got '%s'", usage)
+	}
+	if filterMap := readCommandFilters("x"); filterMap["name"] != "a" {
+		t.Errorf("Expected the filters of the first section, got '%v'", filterMap)
+	}
+
+	if _, _, _, _, _, ok := ParseCommandline([]string{"dirty"}, GetFilterDefs()); ok {
+		t.Error("Expected an unknown filter in filters to fail")
+	}
+}
+
+func TestResolveShortcut(t *testing.T) {
+	savedConfigs := parentConfigs
+	defer func() { parentConfigs = savedConfigs }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{
+			"shortcut \"customers\"": {"root": "/customers", "remoteurl": "github.com"},
+			"shortcut \"develop\"":   {"extends": "customers", "branch": "develop"},
+			"shortcut \"mine\"":      {"extends": "develop", "remoteurl": "github.com/marcelfw"},
+			"shortcut \"loop1\"":     {"extends": "loop2"},
+			"shortcut \"loop2\"":     {"extends": "loop1"},
+			"shortcut \"other\"":     {"root": "/other"},
+		}, nil},
+	}
+
+	filterMap, err := resolveShortcut("mine", nil)
+	expected := map[string]string{"root": "/customers", "branch": "develop", "remoteurl": "github.com/marcelfw"}
+	if err != nil || !reflect.DeepEqual(filterMap, expected) {
+		t.Errorf("Expected shortcut to be '%v', got '%v' (error '%v')", expected, filterMap, err)
+	}
+
+	filterMap, err = combineShortcuts([]string{"develop", "other"})
+	expected = map[string]string{"root": "/other", "branch": "develop", "remoteurl": "github.com"}
+	if err != nil || !reflect.DeepEqual(filterMap, expected) {
+		t.Errorf("Expected combined shortcuts to be '%v', got '%v' (error '%v')", expected, filterMap, err)
+	}
+
+	if _, err := resolveShortcut("loop1", nil); err == nil {
+		t.Error("Expected cycle to be detected")
+	}
+	if _, err := resolveShortcut("missing", nil); err == nil {
+		t.Error("Expected missing shortcut to fail")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MGIT_TEST_ROOT", "/src")
+
+	files := map[string]string{
+		"main":            "[include]\npath = conf.d/*.ini, main\n\n[shortcut \"a\"]\nroot = ${MGIT_TEST_ROOT}/a\nname = $MGIT_TEST_UNSET\nlabel = $$MGIT_TEST_ROOT\nif = test -d $MGIT_TEST_ROOT\n\n[command \"b\"]\nshell = echo $MGIT_TEST_ROOT $$\nstep1 = exec echo $MGIT_TEST_ROOT\nusage = In $MGIT_TEST_ROOT\n",
+		"conf.d/b.ini":    "[shortcut \"b\"]\nroot = $MGIT_TEST_ROOT/b\n",
+		"conf.d/skip.txt": "[shortcut \"c\"]\n",
+	}
+	if err := os.Mkdir(filepath.Join(dir, "conf.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configs := loadConfig(filepath.Join(dir, "main"), nil, make(map[string]bool))
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 configuration files, got %d", len(configs))
+	}
+	if value, _ := configs[0].config.Get("shortcut \"a\"", "root"); value != "/src/a" {
+		t.Errorf("Expected root to be expanded to '/src/a', got '%s'", value)
+	}
+	if value, _ := configs[0].config.Get("shortcut \"a\"", "name"); value != "$MGIT_TEST_UNSET" {
+		t.Errorf("Expected unset variable to be kept, got '%s'", value)
+	}
+	if value, _ := configs[0].config.Get("shortcut \"a\"", "label"); value != "$MGIT_TEST_ROOT" {
+		t.Errorf("Expected $$ to become $, got '%s'", value)
+	}
+	if value, _ := configs[0].config.Get("shortcut \"a\"", "if"); value != "test -d $MGIT_TEST_ROOT" {
+		t.Errorf("Expected if script to keep its variables, got '%s'", value)
+	}
+	for key, expected := range map[string]string{"shell": "echo $MGIT_TEST_ROOT $$", "step1": "exec echo $MGIT_TEST_ROOT", "usage": "In /src"} {
+		if value, _ := configs[0].config.Get("command \"b\"", key); value != expected {
+			t.Errorf("Expected %s to be '%s', got '%s'", key, expected, value)
+		}
+	}
+	if value, _ := configs[1].config.Get("shortcut \"b\"", "root"); value != "/src/b" {
+		t.Errorf("Expected included root to be '/src/b', got '%s'", value)
+	}
+}
+
+func TestEnvironmentFlags(t *testing.T) {
+	filters := make([]repository.FilterDefinition, 0)
+
+	t.Setenv("MGIT_ROOT", "/env")
+	t.Setenv("MGIT_DEPTH", "3")
+	t.Setenv("MGIT_JOBS", "2")
+
+	_, _, _, repFilter, options, ok := ParseCommandline([]string{"-depth", "5", "list"}, filters)
+	if !ok {
+		t.Fatalf("Expected ok to be true, but got %v", ok)
+	}
+	st := reflect.ValueOf(repFilter)
+	if value := st.FieldByName("rootDirectory"); value.String() != "/env" {
+		t.Errorf("Expected rootDirectory from environment to be '/env', got '%v'", value)
+	}
+	if value := st.FieldByName("depth"); value.Int() != 5 {
+		t.Errorf("Expected depth from command-line to be '5', got '%v'", value)
+	}
+	if options.Jobs != 2 {
+		t.Errorf("Expected jobs from environment to be '2', got '%v'", options.Jobs)
+	}
+
+	t.Setenv("MGIT_DEPTH", "deep")
+	if _, _, _, _, _, ok := ParseCommandline([]string{"list"}, filters); ok {
+		t.Error("Expected invalid MGIT_DEPTH to fail")
+	}
+
+	// run by mgit itself
+	t.Setenv("MGIT_NAME", "parent")
+	_, _, _, repFilter, options, ok = ParseCommandline([]string{"list"}, filters)
+	if !ok {
+		t.Fatalf("Expected ok to be true, but got %v", ok)
+	}
+	if value := reflect.ValueOf(repFilter).FieldByName("rootDirectory"); value.String() == "/env" {
+		t.Errorf("Expected MGIT_ROOT to be ignored when MGIT_NAME is set, got '%v'", value)
+	}
+	if options.Jobs != 0 {
+		t.Errorf("Expected MGIT_JOBS to be ignored when MGIT_NAME is set, got '%v'", options.Jobs)
+	}
+}
+
+func TestParseCommandFlags(t *testing.T) {
+	savedConfigs := parentConfigs
+	defer func() { parentConfigs = savedConfigs }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{
+			"command \"list\"": {"columns": "name,path", "all": "false"},
+		}, nil},
+	}
+
+	flagCommand := builtinCommands()["list"].(repository.FlagCommand)
+
+	cmd, args, ok := ParseCommandFlags("list", flagCommand, []string{"-all", "extra"})
+	if !ok || !reflect.DeepEqual(args, []string{"extra"}) {
+		t.Fatalf("Expected arguments '[extra]', got '%v' (ok %v)", args, ok)
+	}
+	header := cmd.Init(args, false).(repository.RowOutputCommand).Header()
+	if len(header) != 7 {
+		t.Errorf("Expected -all to show 7 columns, got '%v'", header)
+	}
+
+	cmd, args, _ = ParseCommandFlags("list", flagCommand, nil)
+	header = cmd.Init(args, false).(repository.RowOutputCommand).Header()
+	if expected := []string{"Name", "Path"}; !reflect.DeepEqual(header, expected) {
+		t.Errorf("Expected columns from configuration '%v', got '%v'", expected, header)
+	}
+
+	if _, _, ok := ParseCommandFlags("list", flagCommand, []string{"-unknown"}); ok {
+		t.Error("Expected unknown flag to fail")
+	}
+
+	parentConfigs[0].config["command \"list\""]["all"] = "maybe"
+	if _, _, ok := ParseCommandFlags("list", flagCommand, nil); ok {
+		t.Error("Expected invalid value in the configuration to fail")
+	}
+}
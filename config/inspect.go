@@ -0,0 +1,500 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package config implements configuration and start-up.
+// This source shows and validates the configuration.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/marcelfw/mgit/command"
+	"github.com/marcelfw/mgit/engine"
+	"github.com/marcelfw/mgit/repository"
+	go_ini "github.com/vaughan0/go-ini"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var sectionRegexp *regexp.Regexp
+
+func init() {
+	sectionRegexp = regexp.MustCompile("^([A-Za-z]+)(?: \"(.*)\")?$")
+}
+
+// configValue is a single value with the file it was read from.
+type configValue struct {
+	key   string
+	value string
+	file  string
+}
+
+// configProblem is something wrong in a configuration file.
+type configProblem struct {
+	file    string
+	line    int // 0 if not known
+	section string
+	key     string
+	message string
+	fatal   bool // the file could not be read, so nothing of it is used
+}
+
+// String returns the problem like "file:line: [section] key: message".
+func (problem configProblem) String() string {
+	location := problem.file
+	if problem.line > 0 {
+		location += ":" + strconv.Itoa(problem.line)
+	}
+	if problem.section != "" {
+		location += ": [" + problem.section + "]"
+		if problem.key != "" {
+			location += " " + problem.key
+		}
+	} else if problem.key != "" {
+		location += ": " + problem.key
+	}
+	return location + ": " + problem.message
+}
+
+type cmdConfig struct {
+	action string
+	args   []string
+}
+
+func NewConfigCommand() cmdConfig {
+	var cmd cmdConfig
+
+	return cmd
+}
+
+func (cmd cmdConfig) Usage() string {
+	return "Show or validate the configuration."
+}
+
+func (cmd cmdConfig) Help() string {
+	return `Show or validate the configuration.
+
+  config list               Show all values which are used
+  config get <section.key>  Show a value, e.g. "shortcut.work.root"
+  config shortcuts          Show all shortcuts with their filters
+  config commands           Show all commands
+  config validate           Report unknown keys and malformed sections
+
+Values are shown with the file they were read from. Of shortcuts,
+commands and the local section only the first section with the same
+name is used, searching the files from the current directory up before
+the global files. Repo and group sections of all files are combined.
+Filters a shortcut extends are shown with the file of that shortcut.
+
+"config validate" exits with an error when it finds problems.`
+}
+
+func (cmd cmdConfig) Init(args []string, interactive bool) (outCmd repository.Command) {
+	if len(args) >= 1 {
+		cmd.action = args[0]
+		cmd.args = args[1:]
+	}
+	return cmd
+}
+
+func (cmd cmdConfig) Output(commands map[string]repository.Command, version string) string {
+	output, _ := cmd.OutputError(commands, version)
+	return output
+}
+
+// OutputError returns the output, with an error if the configuration has problems.
+func (cmd cmdConfig) OutputError(commands map[string]repository.Command, version string) (string, error) {
+	switch cmd.action {
+	case "list":
+		return formatValues(effectiveValues()), nil
+	case "get":
+		if len(cmd.args) != 1 {
+			return "", errors.New("Specify one key like \"shortcut.work.root\".")
+		}
+		values := make([]configValue, 0, 1)
+		for _, value := range effectiveValues() {
+			if value.key == cmd.args[0] {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			return "", errors.New("Key \"" + cmd.args[0] + "\" not found.")
+		}
+		return formatValues(values), nil
+	case "shortcuts":
+		return formatShortcuts(), nil
+	case "commands":
+		return formatCommands(commands), nil
+	case "validate":
+		problems := validateConfigs(GetFilterDefs())
+		if len(problems) == 0 {
+			return "Configuration is valid.", nil
+		}
+		lines := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			lines = append(lines, problem.String())
+		}
+		return strings.Join(lines, "\n"), fmt.Errorf("Found %d problems in the configuration.", len(problems))
+	}
+	return cmd.Help(), nil
+}
+
+// usesFirstSection returns true if only the first section with this name is used.
+func usesFirstSection(section string) bool {
+	return localRegexp.MatchString(section) || shortcutRegexp.MatchString(section) || commandRegexp.MatchString(section)
+}
+
+// sectionKey returns the section like git shows it, "shortcut \"work\"" becomes "shortcut.work".
+func sectionKey(section string) string {
+	if match := sectionRegexp.FindStringSubmatch(section); len(match) >= 3 && match[2] != "" {
+		return match[1] + "." + match[2]
+	}
+	return section
+}
+
+// sortedKeys returns the keys of a section in order.
+func sortedKeys(vars go_ini.Section) []string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedSections returns the section names of a file in order.
+func sortedSections(config go_ini.File) []string {
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// effectiveValues returns all values which are used in order of precedence.
+func effectiveValues() []configValue {
+	values := make([]configValue, 0, 100)
+	seen := make(map[string]bool)
+
+	for _, configs := range []configFiles{parentConfigs, globalConfigs} {
+		for _, config := range configs {
+			for _, section := range sortedSections(config.config) {
+				if usesFirstSection(section) {
+					if seen[section] {
+						continue
+					}
+					seen[section] = true
+				}
+
+				prefix := sectionKey(section)
+				if prefix != "" {
+					prefix += "."
+				}
+				vars := config.config[section]
+				for _, key := range sortedKeys(vars) {
+					values = append(values, configValue{prefix + key, vars[key], config.file})
+				}
+			}
+		}
+	}
+
+	return values
+}
+
+// findSection returns the file of the shortcut, command or local section which is used.
+func findSection(section string) (string, bool) {
+	for _, sectionType := range []*regexp.Regexp{localRegexp, shortcutRegexp, commandRegexp} {
+		if match := sectionType.FindStringSubmatch(section); match != nil {
+			name := ""
+			if len(match) >= 2 {
+				name = match[1]
+			}
+			_, file, ok := readSection(*sectionType, name)
+			return file, ok
+		}
+	}
+	return "", false
+}
+
+// usedSections returns the sections used for a run with their file: the shortcuts (with the shortcuts
+// they extend) or the local section, and the section of the command.
+func usedSections(shortcuts []string, commandName string) map[string]string {
+	used := make(map[string]string)
+
+	use := func(section string) bool {
+		if _, ok := used[section]; ok {
+			return false
+		}
+		file, ok := findSection(section)
+		if ok {
+			used[section] = file
+		}
+		return ok
+	}
+
+	var useShortcut func(name string)
+	useShortcut = func(name string) {
+		if use("shortcut \"" + name + "\"") {
+			vars, _ := readShortcutFromConfiguration(name)
+			for _, parent := range repository.SplitList(vars["extends"]) {
+				useShortcut(parent)
+			}
+		}
+	}
+
+	if len(shortcuts) > 0 {
+		for _, name := range shortcuts {
+			useShortcut(name)
+		}
+	} else {
+		use("local")
+	}
+	use("command \"" + commandName + "\"")
+
+	return used
+}
+
+// checkUsedSections prints the problems in the configuration.
+// Returns false if a file could not be read or a section which is used has problems,
+// other problems are only warnings.
+func checkUsedSections(filterDefs []repository.FilterDefinition, used map[string]string) bool {
+	ok := true
+	for _, problem := range validateConfigs(filterDefs) {
+		if file, found := used[problem.section]; problem.fatal || (found && file == problem.file) {
+			fmt.Println(problem)
+			ok = false
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning:", problem)
+		}
+	}
+	return ok
+}
+
+func formatValues(values []configValue) string {
+	rows := make([][]string, 0, len(values))
+	for _, value := range values {
+		rows = append(rows, []string{value.key, value.value, value.file})
+	}
+	return strings.TrimRight(engine.ReturnTextTable([]string{"Key", "Value", "File"}, rows), "\n")
+}
+
+func formatShortcuts() string {
+	rows := make([][]string, 0, 10)
+	seen := make(map[string]bool)
+
+	for _, configs := range []configFiles{parentConfigs, globalConfigs} {
+		for _, config := range configs {
+			for _, section := range sortedSections(config.config) {
+				match := shortcutRegexp.FindStringSubmatch(section)
+				if len(match) < 2 || seen[match[1]] {
+					continue
+				}
+				seen[match[1]] = true
+
+				// each filter is shown with the file it is read from, which differs for extended filters
+				values, err := resolveShortcutValues(match[1], nil)
+				if err != nil {
+					rows = append(rows, []string{match[1], err.Error(), config.file})
+					continue
+				}
+				if len(values) == 0 {
+					rows = append(rows, []string{match[1], "", config.file})
+					continue
+				}
+				name := match[1]
+				for _, key := range sortedValueKeys(values) {
+					rows = append(rows, []string{name, key + "=" + values[key].value, values[key].file})
+					name = ""
+				}
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return "No shortcuts configured."
+	}
+	return strings.TrimRight(engine.ReturnTextTable([]string{"Shortcut", "Filter", "File"}, rows), "\n")
+}
+
+// sortedValueKeys returns the keys of the values in order.
+func sortedValueKeys(values map[string]configValue) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatCommands(commands map[string]repository.Command) string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		file, ok := findSection("command \"" + name + "\"")
+		if !ok {
+			file = "(builtin)"
+		}
+		rows = append(rows, []string{name, commands[name].Usage(), file})
+	}
+	return strings.TrimRight(engine.ReturnTextTable([]string{"Command", "Usage", "File"}, rows), "\n")
+}
+
+// checkFlag returns the problem with setting a flag, empty if there is none.
+func checkFlag(flags *flag.FlagSet, key, value string) string {
+	if flags.Lookup(key) == nil {
+		return fmt.Sprintf("unknown key \"%s\"", key)
+	}
+	if err := flags.Set(key, value); err != nil {
+		return fmt.Sprintf("invalid value \"%s\": %v", value, err)
+	}
+	return ""
+}
+
+// validateConfigs returns the problems in all configuration files, ordered by file and line.
+func validateConfigs(filterDefs []repository.FilterDefinition) []configProblem {
+	problems := append(make([]configProblem, 0, 10), loadProblems...)
+
+	mgitFlags, _, _ := newFlagSet(filterDefs)
+
+	// checkFilter returns the problem with a filter, empty if there is none.
+	checkFilter := func(key, value string) string {
+		if key == "s" {
+			return fmt.Sprintf("unknown key \"%s\"", key)
+		}
+		return checkFlag(mgitFlags, key, value)
+	}
+	// checkFilters returns the problem with the filters of a command, empty if there is none.
+	checkFilters := func(value string) string {
+		filterMap := parseFilters(value)
+		for _, key := range sortedKeys(filterMap) {
+			if problem := checkFilter(key, filterMap[key]); problem != "" {
+				return "filter " + key + ": " + problem
+			}
+		}
+		return ""
+	}
+
+	for _, configs := range []configFiles{parentConfigs, globalConfigs} {
+		for _, config := range configs {
+			fileProblems := make([]configProblem, 0)
+			report := func(section, key, message string) {
+				fileProblems = append(fileProblems, configProblem{config.file, config.line(section, key), section, key, message, false})
+			}
+
+			for _, section := range sortedSections(config.config) {
+				vars := config.config[section]
+
+				// checkKey returns the problem with a key, empty if there is none.
+				var checkKey func(key, value string) string
+				only := func(known string) func(string, string) string {
+					return func(key, value string) string {
+						if key != known {
+							return fmt.Sprintf("unknown key \"%s\"", key)
+						}
+						return ""
+					}
+				}
+
+				match := sectionRegexp.FindStringSubmatch(section)
+				switch {
+				case section == "":
+					checkKey = func(key, value string) string { return "value outside of a section" }
+				case match == nil:
+					report(section, "", "malformed section, expected [type] or [type \"name\"]")
+				case match[1] == "local" && match[2] == "", match[1] == "shortcut" && match[2] != "":
+					checkKey = func(key, value string) string {
+						if key == "extends" && match[1] == "shortcut" {
+							return ""
+						}
+						return checkFilter(key, value)
+					}
+				case match[1] == "command" && match[2] != "":
+					kind := command.ConfigKind(vars)
+					if kind == "" {
+						builtin, ok := builtinCommands()[match[2]]
+						if !ok {
+							report(section, "", "command without git, exec, shell or steps")
+							break
+						}
+						checkKey = func(key, value string) string {
+							if key == "filters" {
+								return checkFilters(value)
+							}
+							if isBuiltinConfigKey(match[2], key) {
+								return ""
+							}
+							flagCommand, ok := builtin.(repository.FlagCommand)
+							if !ok {
+								return fmt.Sprintf("unknown key \"%s\"", key)
+							}
+							// configures a flag of the builtin command, which checks the value when it starts
+							commandFlags := flag.NewFlagSet(match[2], flag.ContinueOnError)
+							cmd := flagCommand.AddFlags(commandFlags)
+							if problem := checkFlag(commandFlags, key, value); problem != "" {
+								return problem
+							}
+							if initErrorCommand, ok := cmd.Init(nil, false).(repository.InitErrorCommand); ok && initErrorCommand.InitError() != nil {
+								return fmt.Sprintf("invalid value \"%s\": %v", value, initErrorCommand.InitError())
+							}
+							return ""
+						}
+						break
+					}
+					if cmd, ok := createCommand(vars); ok {
+						if initErrorCommand, ok := cmd.(repository.InitErrorCommand); ok && initErrorCommand.InitError() != nil {
+							report(section, "", initErrorCommand.InitError().Error())
+						}
+					}
+					checkKey = func(key, value string) string {
+						if !command.IsConfigKey(kind, key) {
+							for _, other := range []string{"steps", "git", "exec", "shell"} {
+								if command.IsConfigKey(other, key) {
+									return fmt.Sprintf("key \"%s\" is not used by a command with %s", key, kind)
+								}
+							}
+							return fmt.Sprintf("unknown key \"%s\"", key)
+						}
+						if key == "filters" {
+							return checkFilters(value)
+						}
+						return ""
+					}
+				case match[1] == "repo" && match[2] != "":
+					checkKey = only("labels")
+				case match[1] == "group" && match[2] != "":
+					checkKey = only("members")
+				case match[1] == "include" && match[2] == "":
+					checkKey = only("path")
+				case match[1] == "local" || match[1] == "include":
+					report(section, "", "section does not take a name")
+				case match[1] == "shortcut" || match[1] == "command" || match[1] == "repo" || match[1] == "group":
+					report(section, "", "section needs a name like ["+match[1]+" \"name\"]")
+				default:
+					report(section, "", "unknown section")
+				}
+
+				if checkKey == nil {
+					continue
+				}
+				for _, key := range sortedKeys(vars) {
+					if problem := checkKey(key, vars[key]); problem != "" {
+						report(section, key, problem)
+					}
+				}
+			}
+
+			sort.SliceStable(fileProblems, func(i, j int) bool { return fileProblems[i].line < fileProblems[j].line })
+			problems = append(problems, fileProblems...)
+		}
+	}
+
+	return problems
+}
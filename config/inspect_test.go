@@ -0,0 +1,223 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package config
+
+import (
+	go_ini "github.com/vaughan0/go-ini"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveValues(t *testing.T) {
+	savedParents, savedGlobals := parentConfigs, globalConfigs
+	defer func() { parentConfigs, globalConfigs = savedParents, savedGlobals }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{
+			"shortcut \"work\"": {"root": "/project"},
+			"repo \"api-*\"":    {"labels": "backend"},
+		}, nil},
+	}
+	globalConfigs = configFiles{
+		{"/home/.mgit", go_ini.File{
+			"shortcut \"work\"": {"root": "/home"},
+			"repo \"web\"":      {"labels": "frontend"},
+		}, nil},
+	}
+
+	values := effectiveValues()
+	expected := []configValue{
+		{"repo.api-*.labels", "backend", "/project/.mgit"},
+		{"shortcut.work.root", "/project", "/project/.mgit"},
+		{"repo.web.labels", "frontend", "/home/.mgit"},
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("Expected values '%v', got '%v'", expected, values)
+	}
+	for idx := range expected {
+		if values[idx] != expected[idx] {
+			t.Errorf("Expected value '%v', got '%v'", expected[idx], values[idx])
+		}
+	}
+}
+
+func TestValidateConfigs(t *testing.T) {
+	savedParents, savedGlobals := parentConfigs, globalConfigs
+	defer func() { parentConfigs, globalConfigs = savedParents, savedGlobals }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{
+			"shortcut \"work\"": {"root": "/project", "remotepath": "github.com", "extends": "other"},
+			"command \"up\"":    {"git": "pull", "jobs": "2", "step1.on-failure": "stop"},
+			"command \"none\"":  {"usage": "Nothing."},
+			"command \"both\"":  {"git": "pull", "exec": "make"},
+			"command \"run\"":   {"exec": "make", "timeout": "1m"},
+			"command \"exec\"":  {"confirm": "yes", "usage": "Run."},
+			"command \"list\"":  {"columns": "name,zzz"},
+			"group":             {"members": "web"},
+			"local":             {"depth": "2"},
+		}, nil},
+	}
+	globalConfigs = nil
+
+	problems := validateConfigs(GetFilterDefs())
+	expected := []string{
+		"/project/.mgit: [command \"both\"] exec: key \"exec\" is not used by a command with git",
+		"/project/.mgit: [command \"exec\"] usage: unknown key \"usage\"",
+		"/project/.mgit: [command \"list\"] columns: invalid value \"name,zzz\": Unknown column \"zzz\".",
+		"/project/.mgit: [command \"none\"]: command without git, exec, shell or steps",
+		"/project/.mgit: [command \"up\"] step1.on-failure: key \"step1.on-failure\" is not used by a command with git",
+		"/project/.mgit: [group]: section needs a name like [group \"name\"]",
+		"/project/.mgit: [shortcut \"work\"] remotepath: unknown key \"remotepath\"",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got '%v'", len(expected), problems)
+	}
+	for idx := range expected {
+		if problems[idx].String() != expected[idx] {
+			t.Errorf("Expected problem '%s', got '%s'", expected[idx], problems[idx].String())
+		}
+	}
+}
+
+func TestValidateConfigLines(t *testing.T) {
+	savedParents, savedGlobals, savedProblems := parentConfigs, globalConfigs, loadProblems
+	defer func() { parentConfigs, globalConfigs, loadProblems = savedParents, savedGlobals, savedProblems }()
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good")
+	bad := filepath.Join(dir, "bad")
+	if err := os.WriteFile(good, []byte("; shared\n[shortcut \"github\"]\nremotepath = github.com\ndepth = deep\n\n[command \"up\"]\ngit = pull\njobs = many\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("[shortcut \"x\"]\nroot\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loadProblems = nil
+	parentConfigs = loadConfig(good, nil, make(map[string]bool))
+	globalConfigs = loadConfig(bad, nil, make(map[string]bool))
+
+	problems := validateConfigs(GetFilterDefs())
+	expected := []string{
+		bad + ":2: invalid syntax \"root\"",
+		good + ":3: [shortcut \"github\"] remotepath: unknown key \"remotepath\"",
+		good + ":4: [shortcut \"github\"] depth: invalid value \"deep\": parse error",
+		good + ":6: [command \"up\"]: Invalid jobs \"many\", expected a number of at least 1.",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got '%v'", len(expected), problems)
+	}
+	for idx := range expected {
+		if problems[idx].String() != expected[idx] {
+			t.Errorf("Expected problem '%s', got '%s'", expected[idx], problems[idx].String())
+		}
+	}
+}
+
+func TestUsedSections(t *testing.T) {
+	savedParents, savedGlobals, savedProblems := parentConfigs, globalConfigs, loadProblems
+	defer func() { parentConfigs, globalConfigs, loadProblems = savedParents, savedGlobals, savedProblems }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{
+			"shortcut \"a\"":   {"extends": "b", "root": "/a"},
+			"command \"up\"":   {"git": "pull"},
+			"shortcut \"bad\"": {"unknown": "x"},
+			"local":            {"depth": "2"},
+		}, nil},
+	}
+	globalConfigs = configFiles{
+		{"/home/.mgit", go_ini.File{
+			"shortcut \"b\"": {"extends": "a", "depth": "3"},
+			"command \"up\"": {"git": "fetch", "unknown": "x"},
+		}, nil},
+	}
+
+	// the first command section runs, so that one is used
+	if usage := AddConfigCommands(builtinCommands())["up"].Usage(); usage != "Run \"git pull\"." {
+		t.Errorf("Expected the command of the first section to run, got '%s'", usage)
+	}
+	used := usedSections([]string{"a"}, "up")
+	expected := map[string]string{
+		"shortcut \"a\"": "/project/.mgit",
+		"shortcut \"b\"": "/home/.mgit",
+		"command \"up\"": "/project/.mgit",
+	}
+	if !reflect.DeepEqual(used, expected) {
+		t.Errorf("Expected used sections '%v', got '%v'", expected, used)
+	}
+	if used = usedSections(nil, "list"); !reflect.DeepEqual(used, map[string]string{"local": "/project/.mgit"}) {
+		t.Errorf("Expected only the local section, got '%v'", used)
+	}
+
+	// the problems of "bad" and the unused command section in /home/.mgit are warnings
+	if !checkUsedSections(GetFilterDefs(), usedSections([]string{"a"}, "up")) {
+		t.Error("Expected problems in unused sections not to stop")
+	}
+	if checkUsedSections(GetFilterDefs(), usedSections([]string{"bad"}, "up")) {
+		t.Error("Expected problems in a used shortcut to stop")
+	}
+	parentConfigs[0].config["command \"up\""]["unknown"] = "x"
+	if checkUsedSections(GetFilterDefs(), usedSections([]string{"a"}, "up")) {
+		t.Error("Expected problems in the command section which runs to stop")
+	}
+	delete(parentConfigs[0].config["command \"up\""], "unknown")
+
+	loadProblems = []configProblem{{file: "/project/sub/.mgit", line: 2, message: "invalid syntax \"broken\"", fatal: true}}
+	if checkUsedSections(GetFilterDefs(), usedSections([]string{"a"}, "up")) {
+		t.Error("Expected a file which could not be read to stop")
+	}
+}
+
+func TestConfigOutput(t *testing.T) {
+	savedParents, savedGlobals, savedProblems := parentConfigs, globalConfigs, loadProblems
+	defer func() { parentConfigs, globalConfigs, loadProblems = savedParents, savedGlobals, savedProblems }()
+
+	parentConfigs = configFiles{
+		{"/project/.mgit", go_ini.File{
+			"shortcut \"a\"":      {"extends": "b", "root": "/a"},
+			"command \"up\"":      {"git": "pull"},
+			"xcommand \"down\"":   {"git": "push"},
+			"shortcut \"a\" rest": {"depth": "1"},
+		}, nil},
+	}
+	globalConfigs = configFiles{
+		{"/home/.mgit", go_ini.File{
+			"shortcut \"b\"": {"depth": "3"},
+			"command \"up\"": {"git": "fetch"},
+		}, nil},
+	}
+	loadProblems = nil
+
+	// the file of a section is the one the command is read from when it runs
+	if file, ok := findSection("command \"up\""); !ok || file != "/project/.mgit" {
+		t.Errorf("Expected command up from /project/.mgit, got '%s'", file)
+	}
+	if _, ok := findSection("command \"down\""); ok {
+		t.Error("Expected \"xcommand\" not to be a command section")
+	}
+	if vars := readCommandSection("down"); vars != nil {
+		t.Errorf("Expected no command down, got '%v'", vars)
+	}
+
+	// extended filters show the file of the shortcut they are read from
+	shortcuts := formatShortcuts()
+	for _, expected := range []string{"a         depth=3  /home/.mgit", "          root=/a  /project/.mgit", "b         depth=3  /home/.mgit"} {
+		if !strings.Contains(shortcuts, expected) {
+			t.Errorf("Expected '%s' in shortcuts, got:\n%s", expected, shortcuts)
+		}
+	}
+
+	validate := NewConfigCommand().Init([]string{"validate"}, false).(cmdConfig)
+	if output, err := validate.OutputError(nil, ""); err == nil || !strings.Contains(output, "unknown section") {
+		t.Errorf("Expected validate to fail on the unknown sections, got '%s', %v", output, err)
+	}
+	parentConfigs = parentConfigs[:0]
+	if output, err := validate.OutputError(nil, ""); err != nil || output != "Configuration is valid." {
+		t.Errorf("Expected a valid configuration, got '%s', %v", output, err)
+	}
+}
@@ -15,6 +15,9 @@ import (
 // git commands non-interactive we automatically pass-through
 var gitPassThru = []string{"status", "fetch", "push", "pull", "log", "commit", "add", "remote", "branch", "archive", "tag"}
 
+// git commands which ask for confirmation before running
+var gitConfirm = []string{"push"}
+
 // Usage returns the usage for the program.
 func Usage(filters []repository.FilterDefinition, commands map[string]repository.Command) string {
 	textBas := `usage: mgit [<filters>] <command> [<args>]
@@ -22,11 +25,17 @@ func Usage(filters []repository.FilterDefinition, commands map[string]repository
 `
 
 	filTable := make([][]string, 0, len(filters)*2+5)
-	filTable = append(filTable, []string{"  -s <shortcut>", "Read shortcut for filters."})
+	filTable = append(filTable, []string{"  -s <shortcut>", "Read shortcut for filters (repeat to combine)."})
 	filTable = append(filTable, []string{"  -root <directory>", "Root directory to search from."})
 	filTable = append(filTable, []string{"  -depth <depth>", "Maximum depth to search in."})
 	filTable = append(filTable, []string{"  -debug", "Show debug output."})
 	filTable = append(filTable, []string{"  -i", "Assume command is interactive."})
+	filTable = append(filTable, []string{"  -n, -dryrun", "Show what would run instead of running it."})
+	filTable = append(filTable, []string{"  -y", "Do not ask for confirmation."})
+	filTable = append(filTable, []string{"  -template <template>", "Render output of each repository with template."})
+	filTable = append(filTable, []string{"  -sort <column>", "Sort output on column (name, branch, status, lastcommit, duration)."})
+	filTable = append(filTable, []string{"  -reverse", "Reverse the sort order."})
+	filTable = append(filTable, []string{"  -jobs <number>", "Number of repositories to run at the same time."})
 	for _, filter := range filters {
 		for flag, help := range filter.Usage() {
 			filTable = append(filTable, []string{"  " + flag, help})
@@ -65,25 +74,57 @@ func GetFilterDefs() []repository.FilterDefinition {
 	filters = append(filters, filter.NewRemoteFilter())
 	filters = append(filters, filter.NewBranchFilter())
 	filters = append(filters, filter.NewTagFilter())
+	filters = append(filters, filter.NewLabelFilter())
+	filters = append(filters, filter.NewConfigFilter())
+	filters = append(filters, filter.NewPathFilter())
+	filters = append(filters, filter.NewCommitFilter())
+	filters = append(filters, filter.NewIfFilter())
 
 	return filters
 }
 
 // getCommands fetches all commands available for this run.
 func GetCommands() map[string]repository.Command {
+	return AddConfigCommands(builtinCommands())
+}
+
+// builtinCommands returns the commands which are available without configuration.
+func builtinCommands() map[string]repository.Command {
 	cmds := make(map[string]repository.Command)
 
 	cmds["help"] = command.NewHelpCommand()
 	cmds["echo"] = command.NewEchoCommand()
-	cmds["exec"] = command.NewExecCommand()
+	cmds["exec"] = command.NewExecCommand(readCommandSection("exec"))
+	cmds["sh"] = command.NewShellCommand(readCommandSection("sh"))
 	cmds["list"] = command.NewListCommand()
+	cmds["path"] = command.NewPathCommand()
+	cmds["shell-init"] = command.NewShellInitCommand()
 	cmds["version"] = command.NewVersionCommand()
+	cmds["config"] = NewConfigCommand()
+	cmds["completion"] = NewCompletionCommand()
 
 	for _, gitCommand := range gitPassThru {
 		cmds[gitCommand] = command.NewGitProxyCommand(gitCommand, map[string]string{})
 	}
-
-	cmds = AddConfigCommands(cmds)
+	for _, gitCommand := range gitConfirm {
+		cmds[gitCommand] = command.NewGitProxyCommand(gitCommand, map[string]string{"confirm": "yes"})
+	}
 
 	return cmds
 }
+
+// builtinConfigKeys are the keys builtin commands read from their command section, besides filters.
+var builtinConfigKeys = map[string][]string{
+	"exec": {"confirm"},
+	"sh":   {"confirm"},
+}
+
+// isBuiltinConfigKey returns true if the builtin command reads the key from its command section.
+func isBuiltinConfigKey(name, key string) bool {
+	for _, configKey := range builtinConfigKeys[name] {
+		if key == configKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package engine implements the engine.
+// This source asks for confirmation before running a command.
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+// askConfirmation asks a yes/no question on the terminal.
+// Without a terminal the answer is always no.
+func askConfirmation(question string) bool {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		fmt.Printf("%s no (not a terminal, use -y to confirm)\n", question)
+		return false
+	}
+
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		// input ended without a newline
+		fmt.Println()
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// confirmRepositories shows the command for each repository and asks for confirmation.
+// Returns false if the command should not run.
+func confirmRepositories(repositories []repository.Repository, command repository.ConfirmCommand) bool {
+	if len(repositories) == 0 {
+		return true
+	}
+
+	rows := make([][]string, 0, len(repositories))
+	for _, repository := range repositories {
+		rows = append(rows, []string{repository.GetShowName(), command.Preview(repository)})
+	}
+	fmt.Print(ReturnTextTable([]string{"Repository", "Command"}, rows))
+
+	return askConfirmation(fmt.Sprintf("Run on %d repositories?", len(repositories)))
+}
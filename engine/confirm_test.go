@@ -0,0 +1,40 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/marcelfw/mgit/repository"
+	"github.com/marcelfw/mgit/testutil"
+)
+
+type confirmCommand struct{}
+
+func (cmd confirmCommand) NeedsConfirmation() bool {
+	return true
+}
+
+func (cmd confirmCommand) Preview(repository repository.Repository) string {
+	return "rm -rf build"
+}
+
+func TestAskConfirmationNoTerminal(t *testing.T) {
+	testutil.WithStdin(t, "y\n", func() {
+		if askConfirmation("Run?") {
+			t.Errorf("Expected no without a terminal")
+		}
+	})
+}
+
+func TestConfirmRepositoriesNoTerminal(t *testing.T) {
+	testutil.WithStdin(t, "y\n", func() {
+		if !confirmRepositories([]repository.Repository{}, confirmCommand{}) {
+			t.Errorf("Expected yes without repositories")
+		}
+		repositories := []repository.Repository{{}}
+		if confirmRepositories(repositories, confirmCommand{}) {
+			t.Errorf("Expected no without a terminal")
+		}
+	})
+}
@@ -7,9 +7,14 @@ package engine
 import (
 	"fmt"
 	"github.com/marcelfw/mgit/repository"
+	"io"
 	"log"
+	"os"
 	"sort"
+	"strings"
 	"sync"
+	"text/template"
+	"time"
 )
 
 // channel size for pushing repositories
@@ -18,9 +23,76 @@ const numCachedRepositories = 100
 // number of parallel processors.
 const numDigesters = 5
 
+// Options holds the options which change how a command is run and shown.
+type Options struct {
+	Sort    string // column to sort on, empty for discovery order
+	Reverse bool   // reverse the order
+	DryRun  bool   // show what would run instead of running it
+	Yes     bool   // assume yes when asked for confirmation
+	Jobs    int    // number of repositories to run at the same time, 0 for the command default
+
+	Template string // render each repository with this template
+}
+
+// filterChannel concurrently applies the remaining filters to the repositories as they are found.
+func filterChannel(inChannel chan repository.Repository, filter repository.RepositoryFilter) chan repository.Repository {
+	outChannel := make(chan repository.Repository, numDigesters)
+	go func() {
+		var wg sync.WaitGroup
+		wg.Add(numDigesters)
+		for i := 0; i < numDigesters; i++ {
+			go func() {
+				for repository := range inChannel {
+					if filter.FilterConcurrent(repository) {
+						outChannel <- repository
+					}
+				}
+				wg.Done()
+			}()
+		}
+		wg.Wait()
+		close(outChannel)
+	}()
+
+	return outChannel
+}
+
+// filterRepositories concurrently applies the remaining filters and returns the repositories in order.
+// Each repository is told its position, so the total number of repositories is known.
+func filterRepositories(inChannel chan repository.Repository, filter repository.RepositoryFilter) []repository.Repository {
+	repositories := make([]repository.Repository, 0, 1000)
+	for repository := range filterChannel(inChannel, filter) {
+		repositories = append(repositories, repository)
+	}
+	sort.Sort(repository.ByIndex(repositories))
+
+	for idx := range repositories {
+		repositories[idx].SetPosition(idx, len(repositories))
+	}
+
+	return repositories
+}
+
+// sliceChannel returns a closed channel with the repositories.
+func sliceChannel(repositories []repository.Repository) chan repository.Repository {
+	outChannel := make(chan repository.Repository, len(repositories))
+	for _, repository := range repositories {
+		outChannel <- repository
+	}
+	close(outChannel)
+
+	return outChannel
+}
+
 // goRepositories concurrently performs an action on each repository.
-func goRepositories(inChannel chan repository.Repository, outChannel chan repository.Repository, command repository.RepositoryCommand) {
+func goRepositories(inChannel chan repository.Repository, outChannel chan repository.Repository, command repository.RepositoryCommand, jobs int) {
 	digesters := numDigesters
+	if parallelCommand, ok := command.(repository.ParallelCommand); ok && parallelCommand.Jobs() > 0 {
+		digesters = parallelCommand.Jobs()
+	}
+	if jobs > 0 {
+		digesters = jobs
+	}
 	if command.IsInteractive() {
 		digesters = 1
 	}
@@ -29,8 +101,10 @@ func goRepositories(inChannel chan repository.Repository, outChannel chan reposi
 	wg.Add(digesters)
 	for i := 0; i < digesters; i++ {
 		go func() {
-			for repository := range inChannel {
-				if outRepository, output := command.Run(repository); output == true {
+			for repos := range inChannel {
+				start := time.Now()
+				if outRepository, output := command.Run(repos); output == true {
+					outRepository.PutInfo(repository.DurationInfo, time.Since(start))
 					outChannel <- outRepository
 				}
 			}
@@ -41,14 +115,57 @@ func goRepositories(inChannel chan repository.Repository, outChannel chan reposi
 }
 
 // Run the actual command with the filter.
-func RunCommand(command repository.RepositoryCommand, filter repository.RepositoryFilter) {
-	// Find repositories which match filter and put on inchannel.
-	inChannel := repository.FindRepositories(filter, numCachedRepositories)
+// Returns false if the command could not run or failed for every repository.
+func RunCommand(command repository.RepositoryCommand, filter repository.RepositoryFilter, options Options) bool {
+	var sortValue sortValueFunc
+	if options.Sort != "" {
+		var ok bool
+		if sortValue, ok = getSortValueFunc(command, options.Sort); !ok {
+			fmt.Printf("Cannot sort on \"%s\".\n", options.Sort)
+			return false
+		}
+	}
+
+	// Check the output template before anything runs.
+	var outputTemplate *template.Template
+	if options.Template == "" {
+		if templateCommand, ok := command.(repository.TemplateCommand); ok {
+			options.Template = templateCommand.Template()
+		}
+	}
+	if options.Template != "" {
+		var err error
+		if outputTemplate, err = repository.ParseTemplate(options.Template); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+	}
+
+	confirmCommand, confirm := command.(repository.ConfirmCommand)
+	confirm = confirm && confirmCommand.NeedsConfirmation() && !options.Yes && !options.DryRun
+	positionCommand, position := command.(repository.PositionCommand)
+	position = position && positionCommand.NeedsPosition()
+
+	// Find repositories which match filter.
+	// The command runs while repositories are found, unless all of them must be known first.
+	var foundChannel chan repository.Repository
+	if confirm || position {
+		found := filterRepositories(repository.FindRepositories(filter, numCachedRepositories), filter)
+		foundChannel = sliceChannel(found)
+
+		// Show what will run and ask for confirmation.
+		if confirm && !confirmRepositories(found, confirmCommand) {
+			fmt.Println("Aborted.")
+			return false
+		}
+	} else {
+		foundChannel = filterChannel(repository.FindRepositories(filter, numCachedRepositories), filter)
+	}
 
 	// Get additional information about repositories and put on outChannel.
 	outChannel := make(chan repository.Repository, numDigesters)
 	go func() {
-		goRepositories(inChannel, outChannel, command)
+		goRepositories(foundChannel, outChannel, command, options.Jobs)
 		close(outChannel)
 	}()
 
@@ -59,10 +176,21 @@ func RunCommand(command repository.RepositoryCommand, filter repository.Reposito
 	}
 
 	// Sort repositories for logical output.
-	sort.Sort(repository.ByIndex(repositories))
+	sortRepositories(repositories, sortValue, options.Reverse)
 
 	// Repository output.
-	if rowOutputCommand, ok := command.(repository.RowOutputCommand); ok {
+	if reduceCommand, ok := command.(repository.ReduceCommand); ok {
+		output, err := reduceCommand.Reduce(repositories)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+		fmt.Println(output)
+	} else if outputTemplate != nil {
+		if !writeTemplateOutput(os.Stdout, os.Stderr, repositories, outputTemplate) {
+			return false
+		}
+	} else if rowOutputCommand, ok := command.(repository.RowOutputCommand); ok {
 		rows := make([][]string, 0, len(repositories))
 		for _, repository := range repositories {
 			output := rowOutputCommand.Output(repository)
@@ -102,4 +230,37 @@ func RunCommand(command repository.RepositoryCommand, filter repository.Reposito
 
 		fmt.Print(output)
 	}
+
+	return !allFailed(repositories)
+}
+
+// writeTemplateOutput writes the template for each repository to out and errors to errOut.
+// Returns false if the template failed for a repository.
+func writeTemplateOutput(out io.Writer, errOut io.Writer, repositories []repository.Repository, outputTemplate *template.Template) bool {
+	ok := true
+	output := ""
+	for _, repository := range repositories {
+		line, err := repository.ApplyTemplate(outputTemplate)
+		if err != nil {
+			fmt.Fprintln(errOut, repository.GetShowName()+": "+err.Error())
+			ok = false
+			continue
+		}
+		if line != "" {
+			output += strings.TrimRight(line, "\n") + "\n"
+		}
+	}
+
+	fmt.Fprint(out, output)
+	return ok
+}
+
+// allFailed returns true if the command failed for every repository, e.g. because of a macro error.
+func allFailed(repositories []repository.Repository) bool {
+	for _, repos := range repositories {
+		if exitCode, _ := repos.GetInfo(repository.ExitCodeInfo).(int); exitCode == 0 {
+			return false
+		}
+	}
+	return len(repositories) > 0
 }
@@ -0,0 +1,122 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+func TestAllFailed(t *testing.T) {
+	var ok, failed, listed repository.Repository
+	ok.PutInfo(repository.ExitCodeInfo, 0)
+	failed.PutInfo(repository.ExitCodeInfo, -1)
+
+	tests := []struct {
+		repositories []repository.Repository
+		expected     bool
+	}{
+		{nil, false},
+		{[]repository.Repository{failed}, true},
+		{[]repository.Repository{failed, failed}, true},
+		{[]repository.Repository{failed, ok}, false},
+		{[]repository.Repository{failed, listed}, false},
+	}
+
+	for i, test := range tests {
+		if result := allFailed(test.repositories); result != test.expected {
+			t.Errorf("Test %d: expected %v, got %v", i, test.expected, result)
+		}
+	}
+}
+
+func TestTemplateOutput(t *testing.T) {
+	outputTemplate, err := repository.ParseTemplate("{{ .ExitCode }} {{ index (split \":\" .Output) 1 }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second, empty repository.Repository
+	first.PutInfo(repository.OutputInfo, "a:up to date")
+	first.PutInfo(repository.ExitCodeInfo, 0)
+	second.PutInfo(repository.OutputInfo, "b:failed\n")
+	second.PutInfo(repository.ExitCodeInfo, 1)
+	empty.PutInfo(repository.OutputInfo, "")
+
+	var out, errOut bytes.Buffer
+	if !writeTemplateOutput(&out, &errOut, []repository.Repository{first, second}, outputTemplate) {
+		t.Error("Expected the template to work for all repositories")
+	}
+	if expected := "0 up to date\n1 failed\n"; out.String() != expected || errOut.Len() != 0 {
+		t.Errorf("Expected output '%s', got '%s' and errors '%s'", expected, out.String(), errOut.String())
+	}
+
+	// the error goes to errOut, the other repositories are still shown
+	out.Reset()
+	if writeTemplateOutput(&out, &errOut, []repository.Repository{empty, first}, outputTemplate) {
+		t.Error("Expected the template to fail for a repository")
+	}
+	if out.String() != "0 up to date\n" || !bytes.Contains(errOut.Bytes(), []byte("(root): template error")) {
+		t.Errorf("Expected only the working repository in the output, got '%s' and errors '%s'", out.String(), errOut.String())
+	}
+}
+
+// keepFilter is a concurrent filter which keeps repositories with "keep" in their name.
+type keepFilter struct{}
+
+func (filter keepFilter) FilterRepository(repos repository.Repository) bool {
+	return strings.Contains(repos.GetShowName(), "keep")
+}
+
+func (filter keepFilter) Concurrent() {}
+
+func TestFilterRepositories(t *testing.T) {
+	dir := t.TempDir()
+	repositories := make([]repository.Repository, 0, 10)
+	expected := make([]string, 0, 10)
+	for idx := 0; idx < 10; idx++ {
+		name := "drop" + string(rune('a'+idx))
+		if idx%3 == 0 {
+			name = "keep" + string(rune('a'+idx))
+			expected = append(expected, name)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, name, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		repos, ok := repository.NewRepository(idx, name, filepath.Join(dir, name, ".git"))
+		if !ok {
+			t.Fatalf("Expected repository %s", name)
+		}
+		repositories = append(repositories, repos)
+	}
+	filter := repository.NewRepositoryFilter(dir, 1, []repository.Filter{keepFilter{}})
+
+	// filterChannel keeps the matching repositories in any order
+	names := make([]string, 0, len(expected))
+	for repos := range filterChannel(sliceChannel(repositories), filter) {
+		names = append(names, repos.GetShowName())
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected repositories %v, got %v", expected, names)
+	}
+
+	// filterRepositories returns them in discovery order with their position
+	found := filterRepositories(sliceChannel(repositories), filter)
+	for idx, repos := range found {
+		if repos.GetShowName() != expected[idx] {
+			t.Errorf("Expected %s at %d, got %s", expected[idx], idx, repos.GetShowName())
+		}
+		environment := strings.Join(repos.GetEnvironment(), " ")
+		if position := "MGIT_INDEX=" + string(rune('1'+idx)) + " MGIT_TOTAL=4"; !strings.Contains(environment, position) {
+			t.Errorf("Expected %s in the environment, got %s", position, environment)
+		}
+	}
+}
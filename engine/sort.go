@@ -0,0 +1,105 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package engine implements the engine.
+// This source sorts the repositories before output.
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+// sortValueFunc returns the value to sort a repository on.
+type sortValueFunc func(*repository.Repository) string
+
+// builtin sort columns available for all commands.
+var builtinSorts = map[string]sortValueFunc{
+	"name": func(repos *repository.Repository) string {
+		return repos.GetShowName()
+	},
+	"branch": func(repos *repository.Repository) string {
+		return repos.GetCurrentBranch()
+	},
+	"status": func(repos *repository.Repository) string {
+		return repos.GetStatusJudgement()
+	},
+	"lastcommit": func(repos *repository.Repository) string {
+		if commit, ok := repos.GetLastCommit(); ok {
+			return commit.Time.UTC().Format(time.RFC3339)
+		}
+		return ""
+	},
+	"duration": func(repos *repository.Repository) string {
+		if duration, ok := repos.GetInfo(repository.DurationInfo).(time.Duration); ok {
+			return fmt.Sprintf("%020d", int64(duration))
+		}
+		return ""
+	},
+}
+
+// normalizeColumn makes "Last commit" match "lastcommit".
+func normalizeColumn(column string) string {
+	return strings.ToLower(strings.Replace(column, " ", "", -1))
+}
+
+// getSortValueFunc returns the function to get the sort value for the column.
+// Columns declared by the command have precedence over the builtin columns.
+func getSortValueFunc(command repository.RepositoryCommand, column string) (sortValueFunc, bool) {
+	if sortableCommand, ok := command.(repository.SortableCommand); ok {
+		for _, sortColumn := range sortableCommand.SortColumns() {
+			if normalizeColumn(sortColumn) == normalizeColumn(column) {
+				sortColumn := sortColumn
+				return func(repos *repository.Repository) string {
+					return sortableCommand.SortValue(*repos, sortColumn)
+				}, true
+			}
+		}
+	}
+
+	valueFunc, ok := builtinSorts[normalizeColumn(column)]
+	return valueFunc, ok
+}
+
+// byValue sorts repositories on precalculated values, ties are kept in discovery order.
+type byValue struct {
+	repositories []repository.Repository
+	values       []string
+	reverse      bool
+}
+
+func (a byValue) Len() int { return len(a.repositories) }
+func (a byValue) Swap(i, j int) {
+	a.repositories[i], a.repositories[j] = a.repositories[j], a.repositories[i]
+	a.values[i], a.values[j] = a.values[j], a.values[i]
+}
+func (a byValue) Less(i, j int) bool {
+	if a.reverse {
+		return a.values[i] > a.values[j]
+	}
+	return a.values[i] < a.values[j]
+}
+
+// sortRepositories sorts the repositories in the order requested by the options.
+func sortRepositories(repositories []repository.Repository, valueFunc sortValueFunc, reverse bool) {
+	sort.Sort(repository.ByIndex(repositories))
+
+	if valueFunc == nil {
+		if reverse {
+			for i, j := 0, len(repositories)-1; i < j; i, j = i+1, j-1 {
+				repositories[i], repositories[j] = repositories[j], repositories[i]
+			}
+		}
+		return
+	}
+
+	values := make([]string, len(repositories))
+	for idx := range repositories {
+		values[idx] = valueFunc(&repositories[idx])
+	}
+
+	sort.Stable(byValue{repositories, values, reverse})
+}
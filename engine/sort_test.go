@@ -0,0 +1,92 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+type sortCommand struct{}
+
+func (cmd sortCommand) IsInteractive() bool {
+	return false
+}
+
+func (cmd sortCommand) Run(repository repository.Repository) (repository.Repository, bool) {
+	return repository, true
+}
+
+func (cmd sortCommand) SortColumns() []string {
+	return []string{"Name length"}
+}
+
+func (cmd sortCommand) SortValue(repository repository.Repository, column string) string {
+	return string(rune('a' + len(repository.GetShowName())))
+}
+
+// sortedNames returns the names of the repositories after sorting.
+func sortedNames(t *testing.T, repositories []repository.Repository, column string, reverse bool) []string {
+	var valueFunc sortValueFunc
+	if column != "" {
+		var ok bool
+		if valueFunc, ok = getSortValueFunc(sortCommand{}, column); !ok {
+			t.Fatalf("Expected column %s to sort on", column)
+		}
+	}
+	sortRepositories(repositories, valueFunc, reverse)
+
+	names := make([]string, 0, len(repositories))
+	for _, repos := range repositories {
+		names = append(names, repos.GetShowName())
+	}
+	return names
+}
+
+func TestSortRepositories(t *testing.T) {
+	dir := t.TempDir()
+	repositories := make([]repository.Repository, 0, 3)
+	// discovery order is shop, api, web-frontend
+	for idx, name := range []string{"shop", "api", "web-frontend"} {
+		if err := os.MkdirAll(filepath.Join(dir, name, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		repos, ok := repository.NewRepository(idx, name, filepath.Join(dir, name, ".git"))
+		if !ok {
+			t.Fatalf("Expected repository %s", name)
+		}
+		repos.PutInfo(repository.DurationInfo, time.Duration(3-idx)*time.Second)
+		repositories = append(repositories, repos)
+	}
+	// the order they finished in
+	repositories[0], repositories[2] = repositories[2], repositories[0]
+
+	tests := []struct {
+		column   string
+		reverse  bool
+		expected []string
+	}{
+		{"", false, []string{"shop", "api", "web-frontend"}},
+		{"", true, []string{"web-frontend", "api", "shop"}},
+		{"name", false, []string{"api", "shop", "web-frontend"}},
+		{"Name", true, []string{"web-frontend", "shop", "api"}},
+		{"duration", false, []string{"web-frontend", "api", "shop"}},
+		{"duration", true, []string{"shop", "api", "web-frontend"}},
+		{"name length", false, []string{"api", "shop", "web-frontend"}},
+	}
+
+	for i, test := range tests {
+		if names := sortedNames(t, repositories, test.column, test.reverse); !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, test.expected, names)
+		}
+	}
+
+	if _, ok := getSortValueFunc(sortCommand{}, "size"); ok {
+		t.Error("Expected an unknown column not to sort")
+	}
+}
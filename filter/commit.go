@@ -0,0 +1,146 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package filter implements all internal filters.
+// This code filters on the date and author of the last commit.
+package filter
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+type filterCommit struct {
+	name string
+
+	since  *momentValue
+	before *momentValue
+	author *string
+}
+
+// momentValue is a flag value which holds a moment in time.
+type momentValue struct {
+	text   string
+	moment time.Time
+}
+
+var ageRegexp *regexp.Regexp
+
+// init
+func init() {
+	ageRegexp = regexp.MustCompile("^([0-9]+)([hdwmy])$")
+}
+
+// NewCommitFilter returns a new filterCommit filter.
+func NewCommitFilter() filterCommit {
+	filter := filterCommit{name: "commit"}
+
+	return filter
+}
+
+func (filter filterCommit) Name() string {
+	return filter.name
+}
+
+func (filter filterCommit) Usage() map[string]string {
+	return map[string]string{
+		"-since <age|date>":  "Match when last commit is newer than <age> (2w) or <date> (2006-01-02).",
+		"-before <age|date>": "Match when last commit is older than <age> (1y) or <date> (2006-01-02).",
+		"-author <pattern>":  "Match when last commit author name or email matches <pattern> (\"*@example.com\").",
+	}
+}
+
+func (filter filterCommit) AddFlags(flags *flag.FlagSet) repository.Filter {
+	filter.since = new(momentValue)
+	filter.before = new(momentValue)
+
+	flags.Var(filter.since, "since", "select only when last commit is newer")
+	flags.Var(filter.before, "before", "select only when last commit is older")
+	filter.author = flags.String("author", "", "select only when last commit author matches")
+
+	return filter
+}
+
+func (value *momentValue) String() string {
+	if value == nil {
+		return ""
+	}
+	return value.text
+}
+
+func (value *momentValue) Set(text string) error {
+	moment, err := ParseMoment(text, time.Now())
+	if err != nil {
+		return err
+	}
+	value.text = text
+	value.moment = moment
+	return nil
+}
+
+// ParseMoment converts an age (like "2w") or a date (like "2006-01-02") into a moment in time.
+// Ages are relative to now and support h(ours), d(ays), w(eeks), m(onths) and y(ears).
+func ParseMoment(value string, now time.Time) (time.Time, error) {
+	if match := ageRegexp.FindStringSubmatch(value); len(match) >= 3 {
+		amount, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "h":
+			return now.Add(-time.Duration(amount) * time.Hour), nil
+		case "d":
+			return now.AddDate(0, 0, -amount), nil
+		case "w":
+			return now.AddDate(0, 0, -7*amount), nil
+		case "m":
+			return now.AddDate(0, -amount, 0), nil
+		case "y":
+			return now.AddDate(-amount, 0, 0), nil
+		}
+	}
+
+	if moment, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return moment, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid age or date \"%s\"", value)
+}
+
+func (filter filterCommit) FilterRepository(repos repository.Repository) bool {
+	if filter.since.text == "" && filter.before.text == "" && *filter.author == "" {
+		return true
+	}
+
+	commit, ok := repos.GetLastCommit()
+	if !ok {
+		// without commits there is nothing to match
+		return false
+	}
+
+	if filter.since.text != "" && commit.Time.Before(filter.since.moment) {
+		return false
+	}
+	if filter.before.text != "" && !commit.Time.Before(filter.before.moment) {
+		return false
+	}
+	if *filter.author != "" && !matchAuthor(*filter.author, commit) {
+		return false
+	}
+
+	return true
+}
+
+// matchAuthor returns true if the glob pattern matches the name, the email or "name <email>"
+// of the commit author. Matching ignores case.
+func matchAuthor(pattern string, commit repository.Commit) bool {
+	pattern = strings.ToLower(pattern)
+	for _, value := range []string{commit.Author, commit.Email, commit.Author + " <" + commit.Email + ">"} {
+		if repository.MatchGlob(pattern, strings.ToLower(value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+func TestParseMoment(t *testing.T) {
+	now := time.Date(2014, 12, 15, 12, 0, 0, 0, time.Local)
+
+	tests := map[string]time.Time{
+		"6h":         time.Date(2014, 12, 15, 6, 0, 0, 0, time.Local),
+		"3d":         time.Date(2014, 12, 12, 12, 0, 0, 0, time.Local),
+		"2w":         time.Date(2014, 12, 1, 12, 0, 0, 0, time.Local),
+		"1m":         time.Date(2014, 11, 15, 12, 0, 0, 0, time.Local),
+		"1y":         time.Date(2013, 12, 15, 12, 0, 0, 0, time.Local),
+		"2014-01-01": time.Date(2014, 1, 1, 0, 0, 0, 0, time.Local),
+	}
+
+	for value, expected := range tests {
+		moment, err := ParseMoment(value, now)
+		if err != nil {
+			t.Errorf("Expected '%s' to parse, got error '%v'", value, err)
+		} else if !moment.Equal(expected) {
+			t.Errorf("Expected '%s' to be '%v', got '%v'", value, expected, moment)
+		}
+	}
+
+	for _, value := range []string{"", "2", "w", "2x", "01-01-2014"} {
+		if _, err := ParseMoment(value, now); err == nil {
+			t.Errorf("Expected '%s' not to parse", value)
+		}
+	}
+}
+
+func TestMatchAuthor(t *testing.T) {
+	commit := repository.Commit{Author: "Marcel Wouters", Email: "marcel@example.com"}
+
+	tests := map[string]bool{
+		"Marcel Wouters":                      true,
+		"marcel*":                             true,
+		"*@example.com":                       true,
+		"MARCEL@EXAMPLE.COM":                  true,
+		"Marcel Wouters <marcel@example.com>": true,
+		"* <*@example.com>":                   true,
+		"marcel":                              false,
+		"*@example.org":                       false,
+		"?arcel wouters":                      true,
+	}
+
+	for pattern, expected := range tests {
+		if result := matchAuthor(pattern, commit); result != expected {
+			t.Errorf("Expected -author '%s' to be %v, got %v", pattern, expected, result)
+		}
+	}
+}
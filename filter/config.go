@@ -0,0 +1,78 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package filter implements all internal filters.
+// This code filters on git configuration values.
+package filter
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+type filterConfig struct {
+	name string
+
+	config   *string
+	noconfig *string
+}
+
+// NewConfigFilter returns a new filterConfig filter.
+func NewConfigFilter() filterConfig {
+	filter := filterConfig{name: "config"}
+
+	return filter
+}
+
+func (filter filterConfig) Name() string {
+	return filter.name
+}
+
+func (filter filterConfig) Usage() map[string]string {
+	return map[string]string{
+		"-config <key>[=<glob>]":   "Match when git config <key> is set (and matches <glob>).",
+		"-noconfig <key>[=<glob>]": "Match only when git config <key> is not set (or does not match <glob>).",
+	}
+}
+
+func (filter filterConfig) AddFlags(flags *flag.FlagSet) repository.Filter {
+	filter.config = flags.String("config", "", "select only when this git config key (=value) is found")
+	filter.noconfig = flags.String("noconfig", "", "select only when this git config key (=value) is not found")
+
+	return filter
+}
+
+// hasConfig returns true if the key (with optional "=glob") is found in the git configuration.
+// Git reads the configuration, so global values and includes are found as well.
+func hasConfig(repos repository.Repository, keyValue string) bool {
+	parts := strings.SplitN(keyValue, "=", 2)
+
+	value, _, ok := repos.ExecGit("config", "--get", strings.TrimSpace(parts[0]))
+	if !ok {
+		return false
+	}
+	if len(parts) == 2 {
+		return repository.MatchGlob(strings.TrimSpace(parts[1]), strings.TrimRight(value, "\r\n"))
+	}
+
+	return true
+}
+
+// Concurrent runs git in parallel.
+func (filter filterConfig) Concurrent() {}
+
+func (filter filterConfig) FilterRepository(repos repository.Repository) bool {
+	if *filter.config != "" {
+		if !hasConfig(repos, *filter.config) {
+			return false
+		}
+	}
+	if *filter.noconfig != "" {
+		if hasConfig(repos, *filter.noconfig) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package filter
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+func TestConfigFilter(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	// only the global configuration of the test is used
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	if err := os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[core]\n\teditor = vim\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	git(t, dir, "config", "user.email", "marcel@example.com")
+	git(t, dir, "remote", "add", "origin", "git@github.com:marcelfw/mgit.git")
+	repos, ok := repository.NewRepository(0, "mgit", filepath.Join(dir, ".git"))
+	if !ok {
+		t.Fatal("Expected repository to be found")
+	}
+
+	tests := []struct {
+		config   string
+		noconfig string
+		expected bool
+	}{
+		{"", "", true},
+		{"user.email", "", true},
+		{"user.email=*@example.com", "", true},
+		{"user.email = *@example.org", "", false},
+		{"remote.origin.url=git@github.com:*", "", true},
+		{"Remote.origin.URL", "", true},
+		{"remote.Origin.url", "", false},
+		{"user.name", "", false},
+		{"", "user.name", true},
+		{"", "user.email=*@example.org", true},
+		{"", "user.email", false},
+		{"user.email", "remote.origin.url=https://*", true},
+		{"core.editor=vim", "", true},
+		{"", "core.editor", false},
+	}
+
+	for _, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		filter := NewConfigFilter().AddFlags(flags)
+		flags.Set("config", test.config)
+		flags.Set("noconfig", test.noconfig)
+
+		if result := filter.FilterRepository(repos); result != test.expected {
+			t.Errorf("Expected -config '%s' -noconfig '%s' to be %v, got %v", test.config, test.noconfig, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package filter implements all internal filters.
+// This code filters on the exit status of a shell command.
+package filter
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+type filterIf struct {
+	name string
+
+	command *macroValue
+}
+
+// macroValue is a flag value which holds a parsed macro.
+type macroValue struct {
+	text   string
+	macros repository.Macros
+}
+
+// NewIfFilter returns a new filterIf filter.
+func NewIfFilter() filterIf {
+	filter := filterIf{name: "if"}
+
+	return filter
+}
+
+func (filter filterIf) Name() string {
+	return filter.name
+}
+
+func (filter filterIf) Usage() map[string]string {
+	return map[string]string{
+		"-if <command>": "Match when shell <command> exits with status 0.",
+	}
+}
+
+func (filter filterIf) AddFlags(flags *flag.FlagSet) repository.Filter {
+	filter.command = new(macroValue)
+
+	flags.Var(filter.command, "if", "select only when this shell command succeeds")
+
+	return filter
+}
+
+func (value *macroValue) String() string {
+	if value == nil {
+		return ""
+	}
+	return value.text
+}
+
+func (value *macroValue) Set(text string) error {
+	macros, err := repository.ParseMacros([]string{text})
+	if err != nil {
+		return err
+	}
+	value.text = text
+	value.macros = macros
+	return nil
+}
+
+// Concurrent runs the (probably slow) command in parallel.
+func (filter filterIf) Concurrent() {}
+
+func (filter filterIf) FilterRepository(repos repository.Repository) bool {
+	if filter.command.text == "" {
+		return true
+	}
+
+	args, err := repos.ApplyMacros(filter.command.macros)
+	if err != nil {
+		log.Printf("[%s] filter command has a macro error %v", repos.GetShowName(), err)
+		return false
+	}
+
+	cmd := exec.Command(repository.GetShell(), "-c", args[0])
+	cmd.Dir = repos.GetPath()
+	cmd.Env = append(os.Environ(), repos.GetEnvironment()...)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		log.Printf("[%s] filter command exited with error %v \"%s\"", repos.GetShowName(), err, output)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package filter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+// newIfRepository creates a repository with a marker file and a script which is not executable.
+func newIfRepository(t *testing.T, root, name string, marker bool) repository.Repository {
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// FindRepositories recognizes a git directory by its HEAD and config
+	for _, file := range []string{"HEAD", "config"} {
+		if err := os.WriteFile(filepath.Join(dir, ".git", file), []byte("ref: refs/heads/master\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if marker {
+		if err := os.WriteFile(filepath.Join(dir, "marker"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "check.sh"), []byte("#!/bin/sh\nexit 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repos, ok := repository.NewRepository(0, name, filepath.Join(dir, ".git"))
+	if !ok {
+		t.Fatal("Expected repository to be found")
+	}
+	return repos
+}
+
+func TestIfFilter(t *testing.T) {
+	repos := newIfRepository(t, t.TempDir(), "shop", true)
+
+	tests := []struct {
+		command  string
+		expected bool
+	}{
+		{"", true},
+		{"true", true},
+		{"exit 1", false},
+		{"test -f marker", true},
+		{"test -f missing", false},
+		{"test \"$MGIT_NAME\" = shop", true},
+		{"test {{ .Name }} = shop", true},
+		{"test {{ .Name }} = web", false},
+		{"./check.sh", false}, // not executable
+		{"sh ./check.sh", true},
+	}
+
+	for _, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		filter := NewIfFilter().AddFlags(flags)
+		if err := flags.Set("if", test.command); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := filter.(repository.ConcurrentFilter); !ok {
+			t.Fatal("Expected -if to run concurrently")
+		}
+		if result := filter.FilterRepository(repos); result != test.expected {
+			t.Errorf("Expected -if '%s' to be %v, got %v", test.command, test.expected, result)
+		}
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	NewIfFilter().AddFlags(flags)
+	if err := flags.Set("if", "test {{ .Nmae }}"); err == nil {
+		t.Error("Expected an invalid macro to be rejected")
+	}
+}
+
+func TestIfFilterConcurrent(t *testing.T) {
+	root := t.TempDir()
+	newIfRepository(t, root, "shop", true)
+	newIfRepository(t, root, "web", false)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	filter := NewIfFilter().AddFlags(flags)
+	flags.Set("if", "test -f marker")
+	repositoryFilter := repository.NewRepositoryFilter(root, 2, []repository.Filter{filter})
+
+	// the search itself skips concurrent filters, they are applied afterwards
+	found := make([]string, 0, 2)
+	matched := make([]string, 0, 2)
+	for repos := range repository.FindRepositories(repositoryFilter, 10) {
+		found = append(found, repos.GetShowName())
+		if repositoryFilter.FilterConcurrent(repos) {
+			matched = append(matched, repos.GetShowName())
+		}
+	}
+	sort.Strings(found)
+
+	if len(found) != 2 || found[0] != "shop" || found[1] != "web" {
+		t.Errorf("Expected both repositories to be found, got %v", found)
+	}
+	if len(matched) != 1 || matched[0] != "shop" {
+		t.Errorf("Expected only shop to match, got %v", matched)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package filter implements all internal filters.
+// This code filters on configured labels and groups.
+package filter
+
+import (
+	"flag"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+type filterLabel struct {
+	name string
+
+	label *string
+	group *string
+}
+
+// NewLabelFilter returns a new filterLabel filter.
+func NewLabelFilter() filterLabel {
+	filter := filterLabel{name: "label"}
+
+	return filter
+}
+
+func (filter filterLabel) Name() string {
+	return filter.name
+}
+
+func (filter filterLabel) Usage() map[string]string {
+	return map[string]string{
+		"-label <label>": "Match when repository has <label>.",
+		"-group <group>": "Match when repository is a member of <group>.",
+	}
+}
+
+func (filter filterLabel) AddFlags(flags *flag.FlagSet) repository.Filter {
+	filter.label = flags.String("label", "", "select only with this label")
+	filter.group = flags.String("group", "", "select only when member of this group")
+
+	return filter
+}
+
+func (filter filterLabel) FilterRepository(repos repository.Repository) bool {
+	if *filter.label != "" {
+		if !repos.HasLabel(*filter.label) {
+			return false
+		}
+	}
+	if *filter.group != "" {
+		if !repos.InGroup(*filter.group) {
+			return false
+		}
+	}
+
+	return true
+}
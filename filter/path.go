@@ -0,0 +1,81 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package filter implements all internal filters.
+// This code filters on the presence of files in the repository.
+package filter
+
+import (
+	"flag"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+type filterPath struct {
+	name string
+
+	has    *string
+	hasnot *string
+}
+
+// NewPathFilter returns a new filterPath filter.
+func NewPathFilter() filterPath {
+	filter := filterPath{name: "path"}
+
+	return filter
+}
+
+func (filter filterPath) Name() string {
+	return filter.name
+}
+
+func (filter filterPath) Usage() map[string]string {
+	return map[string]string{
+		"-has <glob>":    "Match when a file matching <glob> is found.",
+		"-hasnot <glob>": "Match only when no file matching <glob> is found.",
+	}
+}
+
+func (filter filterPath) AddFlags(flags *flag.FlagSet) repository.Filter {
+	filter.has = flags.String("has", "", "select only when a file matching this glob is found")
+	filter.hasnot = flags.String("hasnot", "", "select only when no file matching this glob is found")
+
+	return filter
+}
+
+// hasPath returns true if glob matches a file in the repository.
+// Bare repositories are checked against the tree of HEAD.
+func hasPath(repos repository.Repository, glob string) bool {
+	if repos.IsBare() {
+		output, _, ok := repos.ExecGit("--git-dir="+repos.GetGitRoot(), "ls-tree", "-r", "--name-only", "HEAD")
+		if !ok {
+			return false
+		}
+		for _, file := range strings.Split(output, "\n") {
+			if matched, _ := path.Match(glob, file); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	matches, err := filepath.Glob(filepath.Join(repos.GetPath(), glob))
+	return err == nil && len(matches) > 0
+}
+
+func (filter filterPath) FilterRepository(repos repository.Repository) bool {
+	if *filter.has != "" {
+		if !hasPath(repos, *filter.has) {
+			return false
+		}
+	}
+	if *filter.hasnot != "" {
+		if hasPath(repos, *filter.hasnot) {
+			return false
+		}
+	}
+
+	return true
+}
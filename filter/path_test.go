@@ -0,0 +1,87 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package filter
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/marcelfw/mgit/repository"
+)
+
+// git runs git in dir and stops the test when it fails.
+func git(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=mgit", "GIT_AUTHOR_EMAIL=mgit@example.com",
+		"GIT_COMMITTER_NAME=mgit", "GIT_COMMITTER_EMAIL=mgit@example.com")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v %s", args, err, output)
+	}
+}
+
+func TestPathFilter(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	for name, content := range map[string]string{"go.mod": "module x\n", "docs/readme.md": "# x\n"} {
+		file := filepath.Join(work, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git(t, work, "init", "-q")
+	git(t, work, "add", ".")
+	git(t, work, "commit", "-q", "-m", "first")
+	git(t, dir, "clone", "-q", "--bare", "work", "bare.git")
+	// not committed, so only found in the work directory
+	if err := os.WriteFile(filepath.Join(work, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workRepository, ok := repository.NewRepository(0, "work", filepath.Join(work, ".git"))
+	if !ok {
+		t.Fatal("Expected work repository to be found")
+	}
+	bareRepository, ok := repository.NewRepository(1, "bare", filepath.Join(dir, "bare.git"))
+	if !ok || !bareRepository.IsBare() {
+		t.Fatal("Expected bare repository to be found")
+	}
+
+	tests := []struct {
+		has, hasnot string
+		work, bare  bool
+	}{
+		{"go.mod", "", true, true},
+		{"*.mod", "", true, true},
+		{"docs/*.md", "", true, true},
+		{"*.md", "", false, false},
+		{"notes.txt", "", true, false},
+		{"", "go.sum", true, true},
+		{"", "go.mod", false, false},
+		{"go.mod", "notes.txt", false, true},
+	}
+
+	for _, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		filter := NewPathFilter().AddFlags(flags)
+		flags.Set("has", test.has)
+		flags.Set("hasnot", test.hasnot)
+
+		if result := filter.FilterRepository(workRepository); result != test.work {
+			t.Errorf("Expected -has '%s' -hasnot '%s' to be %v for work directory, got %v", test.has, test.hasnot, test.work, result)
+		}
+		if result := filter.FilterRepository(bareRepository); result != test.bare {
+			t.Errorf("Expected -has '%s' -hasnot '%s' to be %v for bare repository, got %v", test.has, test.hasnot, test.bare, result)
+		}
+	}
+}
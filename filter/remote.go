@@ -6,7 +6,6 @@ package filter
 
 import (
 	"flag"
-	"regexp"
 	"strings"
 
 	"github.com/marcelfw/mgit/repository"
@@ -22,13 +21,6 @@ type filterRemote struct {
 	noremoteurl *string
 }
 
-var remoteRegexp *regexp.Regexp
-
-// init
-func init() {
-	remoteRegexp = regexp.MustCompile("remote \"(.+)\"")
-}
-
 // NewRemoteFilter returns a new filterRemote filter.
 func NewRemoteFilter() filterRemote {
 	filter := filterRemote{name: "remote"}
@@ -59,25 +51,8 @@ func (filter filterRemote) AddFlags(flags *flag.FlagSet) repository.Filter {
 	return filter
 }
 
-// getRemotes returns remotes and paths.
-func getRemotes(repository repository.Repository) (remotes map[string]string) {
-	remotes = make(map[string]string)
-
-	for name, vars := range repository.GetConfig() {
-		match := remoteRegexp.FindStringSubmatch(name)
-		if len(match) >= 2 {
-			name := match[1]
-			if value, ok := vars["url"]; ok {
-				remotes[name] = value
-			}
-		}
-	}
-
-	return remotes
-}
-
 func (filter filterRemote) FilterRepository(repos repository.Repository) bool {
-	remotes := getRemotes(repos)
+	remotes := repos.GetRemotes()
 
 	if *filter.remote != "" {
 		if _, ok := remotes[*filter.remote]; !ok {
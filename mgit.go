@@ -25,9 +25,9 @@ func main() {
 		return
 	}
 
-	textCommand, flagInteractive, args, filter, ok := config.ParseCommandline(os.Args[1:], filterDefs)
+	textCommand, flagInteractive, args, filter, options, ok := config.ParseCommandline(os.Args[1:], filterDefs)
 	if ok == false {
-		return
+		os.Exit(1)
 	}
 
 	var curCommand repository.Command
@@ -37,6 +37,13 @@ func main() {
 		return
 	}
 
+	// Let the command parse its own flags.
+	if flagCommand, ok := curCommand.(repository.FlagCommand); ok {
+		if curCommand, args, ok = config.ParseCommandFlags(textCommand, flagCommand, args); ok == false {
+			os.Exit(1)
+		}
+	}
+
 	// Let the command initialize itself with the arguments.
 	initResult := curCommand.Init(args, flagInteractive)
 	// @note no pointer receiver so for now we do this
@@ -44,9 +51,31 @@ func main() {
 		curCommand = newCommand
 	}
 
+	if initErrorCommand, ok := curCommand.(repository.InitErrorCommand); ok {
+		if err := initErrorCommand.InitError(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if dryRunCommand, ok := curCommand.(repository.DryRunCommand); ok && options.DryRun {
+		curCommand = dryRunCommand.DryRun()
+	}
+
 	if repositoryCommand, ok := curCommand.(repository.RepositoryCommand); ok {
 		// Run the actual command.
-		engine.RunCommand(repositoryCommand, filter)
+		if !engine.RunCommand(repositoryCommand, filter, options) {
+			os.Exit(1)
+		}
+	} else if infoErrorCommand, ok := curCommand.(repository.InfoErrorCommand); ok {
+		output, err := infoErrorCommand.OutputError(commands, version)
+		if output != "" {
+			fmt.Fprintln(os.Stdout, output)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	} else if infoCommand, ok := curCommand.(repository.InfoCommand); ok {
 		fmt.Fprintln(os.Stdout, infoCommand.Output(commands, version))
 	} else {
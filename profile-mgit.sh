@@ -1,15 +1,10 @@
 #!/bin/sh
 
-# mcd changes directory to a git repository
+# mcd changes directory to a git repository, see "mgit help shell-init"
 # "mcd ."        changes to the local .mgit root
-# "mcd <name>"   changes t a global directory
-function mcd()
-{
-    if [ "$1" == "." ]; then
-        # use local .mgit root
-        cd `mgit echo "{{ .Path }}" | head -1`
-    else
-        # use global shortcut
-        cd `mgit -s global -name "$1" echo "{{ .Path }}" | tail -1`
-    fi
-}
+# "mcd <name>"   changes to the repository best matching <name> in shortcut "global"
+if [ -n "$ZSH_VERSION" ]; then
+    eval "$(mgit shell-init zsh -s global)"
+else
+    eval "$(mgit shell-init bash -s global)"
+fi
@@ -22,6 +22,14 @@ type Filter interface {
 	FilterRepository(Repository) bool
 }
 
+// ConcurrentFilter is a filter which is too expensive to run during the search.
+// These filters are evaluated in parallel just before the command runs.
+type ConcurrentFilter interface {
+	Filter
+
+	Concurrent() // Marks the filter to run in parallel.
+}
+
 // Command is shared interface used for each command.
 type Command interface {
 	Usage() string // short string describing the usage
@@ -30,6 +38,36 @@ type Command interface {
 	Init(args []string, interactive bool) Command
 }
 
+// InitErrorCommand is a command which reports errors found during Init.
+type InitErrorCommand interface {
+	InitError() error // Return the error found during Init, nil if none.
+}
+
+// FlagCommand is a command with its own flags, given after the command name.
+// Keys in the configuration section of the command set the flags as well.
+type FlagCommand interface {
+	// Add flags for the command-line parser, return the command using them.
+	AddFlags(*flag.FlagSet) Command
+}
+
+// DryRunCommand is a command which can show what it would run instead of running it.
+type DryRunCommand interface {
+	DryRun() Command // Return the command in dry-run mode.
+}
+
+// ConfirmCommand is a command which may ask for confirmation before running.
+type ConfirmCommand interface {
+	NeedsConfirmation() bool // Return true if confirmation is needed.
+
+	Preview(Repository) string // The command as it would run for the repository.
+}
+
+// PositionCommand is a command which needs the position of each repository (MGIT_INDEX and MGIT_TOTAL).
+// Other commands start while repositories are still being found.
+type PositionCommand interface {
+	NeedsPosition() bool // Return true if all repositories must be known before running.
+}
+
 // RepositoryCommand is the interface used commands that act on repositories.
 type RepositoryCommand interface {
 	IsInteractive() bool // Return true if command can be interactive.
@@ -38,6 +76,16 @@ type RepositoryCommand interface {
 	Run(Repository) (Repository, bool)
 }
 
+// ParallelCommand is a repository command which sets its own number of parallel jobs.
+type ParallelCommand interface {
+	Jobs() int // Number of repositories to run at the same time, 0 for the default.
+}
+
+// ReduceCommand is a repository command which reduces all repositories to a single result.
+type ReduceCommand interface {
+	Reduce([]Repository) (string, error) // The output, or the error if there is no result.
+}
+
 // RowOutputCommand is a command which outputs rows.
 type RowOutputCommand interface {
 	Header() []string // Column headers.
@@ -45,6 +93,18 @@ type RowOutputCommand interface {
 	Output(Repository) interface{} // [][]string, []string or string
 }
 
+// SortableCommand is a command which can be sorted on its own columns.
+type SortableCommand interface {
+	SortColumns() []string // Columns which can be sorted on.
+
+	SortValue(Repository, string) string // Value to sort the column on.
+}
+
+// TemplateCommand is a command with its own output template.
+type TemplateCommand interface {
+	Template() string // Output template, empty for the default output.
+}
+
 // LineOutputCommand is a command which outputs lines.
 type LineOutputCommand interface {
 	Header() string
@@ -57,3 +117,8 @@ type LineOutputCommand interface {
 type InfoCommand interface {
 	Output(map[string]Command, string) string // commands and version string
 }
+
+// InfoErrorCommand is an info command which can fail, its output is shown before the error.
+type InfoErrorCommand interface {
+	OutputError(map[string]Command, string) (string, error) // output and the error if it failed
+}
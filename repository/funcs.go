@@ -0,0 +1,149 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package repository implements detection, filtering and structure of repositories.
+// This source defines the functions available in macros.
+package repository
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var scpUrlRegexp *regexp.Regexp
+var urlRegexp *regexp.Regexp
+
+func init() {
+	scpUrlRegexp = regexp.MustCompile("^(?:([^@/]+)@)?([^:/]+):(.+)$")
+	urlRegexp = regexp.MustCompile("^(https?|ssh|git)://(?:([^@/]+)@)?([^/:]+)(?::([0-9]*))?/(.*)$")
+}
+
+// remoteUrl is a remote url split into its parts.
+type remoteUrl struct {
+	scheme string // "" for "user@host:path"
+	user   string
+	host   string
+	port   string
+	path   string
+}
+
+// parseRemoteUrl splits a remote url, ok is false for local paths and file:// urls.
+func parseRemoteUrl(url string) (remote remoteUrl, ok bool) {
+	if match := urlRegexp.FindStringSubmatch(url); match != nil {
+		return remoteUrl{match[1], match[2], match[3], match[4], match[5]}, true
+	}
+	if strings.Contains(url, "://") {
+		return remote, false
+	}
+	if match := scpUrlRegexp.FindStringSubmatch(url); match != nil {
+		return remoteUrl{"", match[1], match[2], "", match[3]}, true
+	}
+	return remote, false
+}
+
+// ToHttpsUrl rewrites a remote url like "git@github.com:user/repo.git" to "https://github.com/user/repo.git".
+// The port of ssh and git urls is dropped, because it is not the port of the web server.
+func ToHttpsUrl(url string) string {
+	remote, ok := parseRemoteUrl(url)
+	if !ok {
+		return url
+	}
+	host := remote.host
+	if remote.port != "" && (remote.scheme == "http" || remote.scheme == "https") {
+		host += ":" + remote.port
+	}
+	return "https://" + host + "/" + strings.TrimLeft(remote.path, "/")
+}
+
+// ToSshUrl rewrites a remote url like "https://github.com/user/repo.git" to "git@github.com:user/repo.git".
+// Ssh urls with a port are kept, because "user@host:path" can't have one.
+func ToSshUrl(url string) string {
+	remote, ok := parseRemoteUrl(url)
+	if !ok || remote.scheme == "" {
+		return url
+	}
+	if remote.scheme != "ssh" {
+		return "git@" + remote.host + ":" + remote.path
+	}
+	if remote.port != "" {
+		return url
+	}
+	user := remote.user
+	if user == "" {
+		user = "git"
+	}
+	// the path of an ssh url is absolute
+	return user + "@" + remote.host + ":/" + remote.path
+}
+
+// UrlHost returns the host of a remote url, or "" for local paths.
+func UrlHost(url string) string {
+	if remote, ok := parseRemoteUrl(url); ok {
+		return remote.host
+	}
+	return ""
+}
+
+// isEmpty returns true for nil, "" and empty lists and maps.
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch reflectValue := reflect.ValueOf(value); reflectValue.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return reflectValue.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return reflectValue.IsNil()
+	}
+	return false
+}
+
+// MacroFuncs returns the functions available in macros and templates.
+// When used in a pipeline the value is always the last argument.
+func MacroFuncs() template.FuncMap {
+	return template.FuncMap{
+		// strings
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"title":      strings.Title,
+		"trim":       strings.TrimSpace,
+		"trimprefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimsuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasprefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hassuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       func(sep string, list []string) string { return strings.Join(list, sep) },
+
+		// paths
+		"base":     path.Base,
+		"dir":      path.Dir,
+		"ext":      path.Ext,
+		"clean":    path.Clean,
+		"abs":      AbsPath,
+		"pathjoin": func(elem ...string) string { return path.Join(elem...) },
+
+		// urls
+		"https":   ToHttpsUrl,
+		"ssh":     ToSshUrl,
+		"urlhost": UrlHost,
+
+		// environment and defaults
+		"env": os.Getenv,
+		"default": func(value string, s interface{}) interface{} {
+			if isEmpty(s) {
+				return value
+			}
+			return s
+		},
+
+		// dates
+		"now":  time.Now,
+		"date": func(layout string, t time.Time) string { return t.Format(layout) },
+	}
+}
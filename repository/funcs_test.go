@@ -0,0 +1,133 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUrlRewriting(t *testing.T) {
+	tests := []struct {
+		url, https, ssh, host string
+	}{
+		{"git@github.com:marcelfw/mgit.git", "https://github.com/marcelfw/mgit.git", "git@github.com:marcelfw/mgit.git", "github.com"},
+		{"https://github.com/marcelfw/mgit.git", "https://github.com/marcelfw/mgit.git", "git@github.com:marcelfw/mgit.git", "github.com"},
+		{"https://github.com/marcelfw/mgit", "https://github.com/marcelfw/mgit", "git@github.com:marcelfw/mgit", "github.com"},
+		{"https://user@git.example.com:8443/x/mgit.git", "https://git.example.com:8443/x/mgit.git", "git@git.example.com:x/mgit.git", "git.example.com"},
+		{"ssh://git@mynas/home/git/mgit.git", "https://mynas/home/git/mgit.git", "git@mynas:/home/git/mgit.git", "mynas"},
+		{"ssh://mynas/home/git/mgit.git", "https://mynas/home/git/mgit.git", "git@mynas:/home/git/mgit.git", "mynas"},
+		{"ssh://git@mynas:2222/home/git/mgit.git", "https://mynas/home/git/mgit.git", "ssh://git@mynas:2222/home/git/mgit.git", "mynas"},
+		{"git://mynas:9418/mgit.git", "https://mynas/mgit.git", "git@mynas:mgit.git", "mynas"},
+		{"mynas:mgit.git", "https://mynas/mgit.git", "mynas:mgit.git", "mynas"},
+		{"file:///home/git/mgit.git", "file:///home/git/mgit.git", "file:///home/git/mgit.git", ""},
+		{"/home/git/mgit.git", "/home/git/mgit.git", "/home/git/mgit.git", ""},
+		{"../mgit.git", "../mgit.git", "../mgit.git", ""},
+	}
+
+	for _, test := range tests {
+		if value := ToHttpsUrl(test.url); value != test.https {
+			t.Errorf("Expected https of '%s' to be '%s', got '%s'", test.url, test.https, value)
+		}
+		if value := ToSshUrl(test.url); value != test.ssh {
+			t.Errorf("Expected ssh of '%s' to be '%s', got '%s'", test.url, test.ssh, value)
+		}
+		if value := UrlHost(test.url); value != test.host {
+			t.Errorf("Expected host of '%s' to be '%s', got '%s'", test.url, test.host, value)
+		}
+	}
+}
+
+func TestMacroFuncs(t *testing.T) {
+	repository := Repository{name: "customer/shop", path: "customer/shop", haveBasics: true, currentBranch: "develop", haveLastCommit: true}
+
+	tests := map[string]string{
+		`{{ .Name | base }}`:                              "shop",
+		`{{ .Name | dir | upper }}`:                       "CUSTOMER",
+		`{{ .CurrentBranch | replace "develop" "main" }}`: "main",
+		`{{ .Remotes.origin | default "none" }}`:          "none",
+		`{{ "a,b" | split "," | join "+" }}`:              "a+b",
+		`{{ "git@example.com:x.git" | https }}`:           "https://example.com/x.git",
+		`{{ .LastCommit.Time | date "2006" }}`:            "0001",
+		`{{ if hasprefix "customer/" .Name }}yes{{end}}`:  "yes",
+	}
+
+	for macro, expected := range tests {
+		macros, err := ParseMacros([]string{macro})
+		if err != nil {
+			t.Errorf("Expected '%s' to parse, got error '%v'", macro, err)
+			continue
+		}
+		if out, err := repository.ApplyMacros(macros); err != nil || out[0] != expected {
+			t.Errorf("Expected '%s' to be '%s', got '%v' (error '%v')", macro, expected, out, err)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	defaultFunc := MacroFuncs()["default"].(func(string, interface{}) interface{})
+
+	var nilList []string
+	var nilMap map[string]string
+	var nilPointer *Repository
+	tests := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{nil, "none"},
+		{"", "none"},
+		{[]string{}, "none"},
+		{nilList, "none"},
+		{map[string]string{}, "none"},
+		{nilMap, "none"},
+		{nilPointer, "none"},
+		{"x", "x"},
+		{0, 0},
+		{false, false},
+	}
+
+	for _, test := range tests {
+		if value := defaultFunc("none", test.value); !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("Expected default of %#v to be %#v, got %#v", test.value, test.expected, value)
+		}
+	}
+	if value := defaultFunc("none", []string{"a"}); !reflect.DeepEqual(value, []string{"a"}) {
+		t.Errorf("Expected a list to be kept, got %#v", value)
+	}
+}
+
+func TestMacroErrors(t *testing.T) {
+	if _, err := ParseMacros([]string{"{{ .Name }}", "{{ .Name "}); err == nil {
+		t.Error("Expected unclosed macro not to parse")
+	}
+	if _, err := ParseMacros([]string{"{{ nosuchfunc .Name }}"}); err == nil {
+		t.Error("Expected unknown function not to parse")
+	}
+
+	// unknown fields are found before any repository is touched, without the type of the data
+	_, err := ParseMacros([]string{"{{ .Name }}", "{{ .NoSuchField }}"})
+	if err == nil || strings.Contains(err.Error(), "macroData") {
+		t.Errorf("Expected unknown field to fail without the type, got '%v'", err)
+	}
+	if _, err := ParseMacros([]string{"{{ .Name 1 }}"}); err == nil {
+		t.Error("Expected wrong arguments to fail")
+	}
+	if _, err := ParseTemplate("{{ .Name }}: {{ .Outptu }}"); err == nil || strings.Contains(err.Error(), "templateData") {
+		t.Errorf("Expected unknown field in template to fail without the type, got '%v'", err)
+	}
+
+	// functions which fail on the values of a repository fail when they are applied
+	macros, err := ParseMacros([]string{"{{ .Name }}", "{{ index (split \"/\" .Name) 1 }}"})
+	if err != nil {
+		t.Fatalf("Expected macros to parse, got error '%v'", err)
+	}
+	repository := Repository{name: "shop"}
+	if _, err := repository.ApplyMacros(macros); err == nil {
+		t.Error("Expected index out of range to fail")
+	}
+	repository = Repository{name: "acme/shop"}
+	if args, err := repository.ApplyMacros(macros); err != nil || args[1] != "shop" {
+		t.Errorf("Expected the second part of the name, got %v, %v", args, err)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package repository implements detection, filtering and structure of repositories.
+// This source assigns labels and groups to repositories.
+package repository
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LabelRule assigns labels to all repositories whose name matches the pattern.
+type LabelRule struct {
+	Pattern string
+	Labels  []string
+}
+
+// labelRules and groups are configured once at start-up.
+var labelRules []LabelRule
+var groups map[string][]string
+
+// SetLabelRules sets the rules used to assign labels to repositories.
+func SetLabelRules(rules []LabelRule) {
+	labelRules = rules
+}
+
+// SetGroups sets the groups with their member name patterns.
+func SetGroups(newGroups map[string][]string) {
+	groups = newGroups
+}
+
+// SplitList splits a comma-separated configuration value into trimmed, non-empty items.
+func SplitList(value string) []string {
+	items := make([]string, 0, 5)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// MatchGlob returns true if value matches the glob pattern.
+// Unlike path.Match a "*" also matches "/", which makes it usable for names, urls and paths.
+func MatchGlob(pattern, value string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.Replace(expr, "\\*", ".*", -1)
+	expr = strings.Replace(expr, "\\?", ".", -1)
+
+	matched, err := regexp.MatchString("^"+expr+"$", value)
+	return err == nil && matched
+}
+
+// GetLabels returns the sorted labels of the repository.
+func (repository *Repository) GetLabels() []string {
+	found := make(map[string]bool)
+	for _, rule := range labelRules {
+		if MatchGlob(rule.Pattern, repository.name) {
+			for _, label := range rule.Labels {
+				found[label] = true
+			}
+		}
+	}
+
+	labels := make([]string, 0, len(found))
+	for label := range found {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	return labels
+}
+
+// HasLabel returns true if the repository has the label.
+func (repository *Repository) HasLabel(label string) bool {
+	for _, repositoryLabel := range repository.GetLabels() {
+		if repositoryLabel == label {
+			return true
+		}
+	}
+	return false
+}
+
+// InGroup returns true if the repository is a member of the group.
+func (repository *Repository) InGroup(group string) bool {
+	for _, pattern := range groups[group] {
+		if MatchGlob(pattern, repository.name) {
+			return true
+		}
+	}
+	return false
+}
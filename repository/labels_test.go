@@ -0,0 +1,58 @@
+// Copyright (c) 2014 Marcel Wouters
+
+package repository
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	matches := map[string]string{
+		"*@example.com":    "marcel@example.com",
+		"git@github.com:*": "git@github.com:marcelfw/mgit.git",
+		"https://*/mgit*":  "https://github.com/marcelfw/mgit.git",
+		"tru?":             "true",
+		"a.b":              "a.b",
+	}
+	for pattern, value := range matches {
+		if !MatchGlob(pattern, value) {
+			t.Errorf("Expected '%s' to match '%s'", pattern, value)
+		}
+	}
+
+	nomatches := map[string]string{
+		"*@example.com": "marcel@example.org",
+		"tru?":          "tru",
+		"a.b":           "axb",
+		"true":          "true ",
+	}
+	for pattern, value := range nomatches {
+		if MatchGlob(pattern, value) {
+			t.Errorf("Expected '%s' not to match '%s'", pattern, value)
+		}
+	}
+}
+
+func TestLabelsAndGroups(t *testing.T) {
+	SetLabelRules([]LabelRule{
+		{"payments-*", []string{"payments", "backend"}},
+		{"*-api", []string{"backend"}},
+	})
+	SetGroups(map[string][]string{"team": SplitList("payments-api, web ,")})
+	defer SetLabelRules(nil)
+	defer SetGroups(nil)
+
+	repository := Repository{name: "payments-api"}
+	if labels := repository.GetLabels(); len(labels) != 2 || labels[0] != "backend" || labels[1] != "payments" {
+		t.Errorf("Expected labels to be '[backend payments]', got '%v'", labels)
+	}
+	if !repository.InGroup("team") {
+		t.Error("Expected repository to be in group 'team'")
+	}
+
+	repository = Repository{name: "website"}
+	if labels := repository.GetLabels(); len(labels) != 0 {
+		t.Errorf("Expected no labels, got '%v'", labels)
+	}
+	if repository.InGroup("team") || repository.InGroup("other") {
+		t.Error("Expected repository not to be in any group")
+	}
+}
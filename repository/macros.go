@@ -0,0 +1,256 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package repository implements detection, filtering and structure of repositories.
+// This source replaces macros with information about the repository.
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// matches the type of the data in template errors, like " in type *repository.macroData"
+var typeRegexp = regexp.MustCompile(` (in|for) type \*?repository\.\w+`)
+
+// info names commands use to store their result, these are available in templates.
+const (
+	OutputInfo   = "output"   // string output of the command
+	ExitCodeInfo = "exitcode" // int exit code of the command
+	DurationInfo = "duration" // time.Duration the command took
+)
+
+// macroData is the data available to macros.
+// Everything that needs git is only retrieved when a macro uses it.
+type macroData struct {
+	repository *Repository
+
+	cache map[string]string // results of git commands
+}
+
+// git runs git once for the macro data and returns the trimmed output.
+// return "" if git failed.
+func (data *macroData) git(args ...string) string {
+	key := strings.Join(args, " ")
+	if value, ok := data.cache[key]; ok {
+		return value
+	}
+
+	value := ""
+	if output, _, ok := data.repository.ExecGit(args...); ok {
+		value = strings.TrimSpace(output)
+	}
+	data.cache[key] = value
+
+	return value
+}
+
+// Name of the repository.
+func (data *macroData) Name() string {
+	return data.repository.name
+}
+
+// Path is the work directory.
+func (data *macroData) Path() string {
+	return data.repository.GetPath()
+}
+
+// GitDir is the git directory.
+func (data *macroData) GitDir() string {
+	return data.repository.GetGitRoot()
+}
+
+// CurrentBranch is the checked out branch.
+func (data *macroData) CurrentBranch() string {
+	return data.repository.GetCurrentBranch()
+}
+
+// DefaultBranch is the branch HEAD of remote origin points to, master if unknown.
+func (data *macroData) DefaultBranch() string {
+	if branch := data.git("symbolic-ref", "--short", "refs/remotes/origin/HEAD"); branch != "" {
+		return strings.TrimPrefix(branch, "origin/")
+	}
+	return "master"
+}
+
+// Upstream is the upstream branch of the current branch.
+func (data *macroData) Upstream() string {
+	return data.git("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
+}
+
+// aheadBehind returns the number of commits ahead and behind the upstream.
+func (data *macroData) aheadBehind() (ahead int, behind int) {
+	counts := strings.Fields(data.git("rev-list", "--left-right", "--count", "HEAD...@{u}"))
+	if len(counts) == 2 {
+		ahead, _ = strconv.Atoi(counts[0])
+		behind, _ = strconv.Atoi(counts[1])
+	}
+	return
+}
+
+// Ahead is the number of commits not in the upstream.
+func (data *macroData) Ahead() int {
+	ahead, _ := data.aheadBehind()
+	return ahead
+}
+
+// Behind is the number of upstream commits not in the current branch.
+func (data *macroData) Behind() int {
+	_, behind := data.aheadBehind()
+	return behind
+}
+
+// Status is the status judgement like in "list".
+func (data *macroData) Status() string {
+	return data.repository.GetStatusJudgement()
+}
+
+// Remotes maps remote names to their url.
+func (data *macroData) Remotes() map[string]string {
+	return data.repository.GetRemotes()
+}
+
+// LastCommit is the last commit on HEAD.
+func (data *macroData) LastCommit() Commit {
+	commit, _ := data.repository.GetLastCommit()
+	return commit
+}
+
+// Tags are the tags pointing at HEAD.
+func (data *macroData) Tags() []string {
+	tags := strings.Fields(data.git("tag", "--points-at", "HEAD"))
+	if tags == nil {
+		tags = []string{}
+	}
+	return tags
+}
+
+// Labels are the configured labels, separated with a comma.
+func (data *macroData) Labels() string {
+	return strings.Join(data.repository.GetLabels(), ", ")
+}
+
+// Macros are parsed arguments which can be applied to each repository.
+type Macros []*template.Template
+
+// checkRepository returns the repository macros and templates are checked with.
+// Its directories don't exist, so git never runs for it.
+func checkRepository() Repository {
+	return Repository{path: os.DevNull, gitRoot: os.DevNull, haveBasics: true, haveLastCommit: true}
+}
+
+// checkTemplate executes the template once, so unknown fields and wrong arguments are found
+// before any repository is touched. Functions which fail on the empty values of the check are
+// not reported, these may work for a real repository.
+func checkTemplate(t *template.Template, data interface{}) error {
+	err := t.Execute(ioutil.Discard, data)
+	if err != nil && !strings.Contains(err.Error(), "error calling ") {
+		return templateError(err)
+	}
+	return nil
+}
+
+// templateError returns the error without the internal type of the data, like "*repository.macroData".
+func templateError(err error) error {
+	return errors.New(typeRegexp.ReplaceAllString(err.Error(), ""))
+}
+
+// ParseMacros parses and checks the arguments, so errors are found before any repository is touched.
+func ParseMacros(args []string) (Macros, error) {
+	checkRepository := checkRepository()
+	data := &macroData{repository: &checkRepository, cache: make(map[string]string)}
+
+	macros := make(Macros, len(args))
+	for idx, arg := range args {
+		t, err := template.New("arg").Funcs(MacroFuncs()).Option("missingkey=zero").Parse(arg)
+		if err == nil {
+			err = checkTemplate(t, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid macro in argument \"%s\": %v", arg, err)
+		}
+		macros[idx] = t
+	}
+	return macros, nil
+}
+
+// ApplyMacros applies the macros to the repository and returns the arguments with replacements.
+func (repository Repository) ApplyMacros(macros Macros) (out []string, err error) {
+	out = make([]string, len(macros))
+
+	data := &macroData{repository: &repository, cache: make(map[string]string)}
+
+	for idx, t := range macros {
+		b := new(bytes.Buffer)
+		if err := t.Execute(b, data); err != nil {
+			log.Printf("[%s] macro failed with error %v", repository.GetShowName(), err)
+			return nil, fmt.Errorf("macro error: %v", templateError(err))
+		}
+		out[idx] = b.String()
+	}
+
+	return out, nil
+}
+
+// templateData is the data available to output templates.
+// Next to the macros it contains the results of the command.
+type templateData struct {
+	*macroData
+}
+
+// Output of the command.
+func (data templateData) Output() string {
+	output, _ := data.repository.GetInfo(OutputInfo).(string)
+	return output
+}
+
+// ExitCode of the command.
+func (data templateData) ExitCode() int {
+	exitCode, _ := data.repository.GetInfo(ExitCodeInfo).(int)
+	return exitCode
+}
+
+// Duration of the command.
+func (data templateData) Duration() time.Duration {
+	duration, _ := data.repository.GetInfo(DurationInfo).(time.Duration)
+	return duration
+}
+
+// Info contains everything the command stored.
+func (data templateData) Info() map[string]interface{} {
+	return data.repository.info
+}
+
+// ParseTemplate parses and checks an output template.
+func ParseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("output").Funcs(MacroFuncs()).Option("missingkey=zero").Parse(text)
+	if err == nil {
+		checkRepository := checkRepository()
+		err = checkTemplate(t, templateData{&macroData{repository: &checkRepository, cache: make(map[string]string)}})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid template \"%s\": %v", text, err)
+	}
+	return t, nil
+}
+
+// ApplyTemplate renders the output template for the repository.
+func (repository Repository) ApplyTemplate(t *template.Template) (string, error) {
+	data := templateData{&macroData{repository: &repository, cache: make(map[string]string)}}
+
+	b := new(bytes.Buffer)
+	if err := t.Execute(b, data); err != nil {
+		log.Printf("[%s] template failed with error %v", repository.GetShowName(), err)
+		return "", fmt.Errorf("template error: %v", templateError(err))
+	}
+
+	return b.String(), nil
+}
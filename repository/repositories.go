@@ -38,6 +38,30 @@ func NewRepositoryFilter(rootDirectory string, depth int, filters []Filter) (fil
 	return filter
 }
 
+// applyFilters returns true if the repository passes all (concurrent or regular) filters.
+func (filter RepositoryFilter) applyFilters(repository Repository, concurrent bool) bool {
+	for _, filter := range filter.filters {
+		if _, isConcurrent := filter.(ConcurrentFilter); isConcurrent != concurrent {
+			continue
+		}
+
+		if filter.FilterRepository(repository) == false {
+			if filterdef, ok := filter.(FilterDefinition); ok {
+				log.Printf("Skipping repository \"%s\" (filtered by %v)", repository.GetShowName(), filterdef.Name())
+			}
+			return false
+		}
+	}
+
+	return true
+}
+
+// FilterConcurrent returns true if the repository passes all concurrent filters.
+// It is safe to call from multiple goroutines.
+func (filter RepositoryFilter) FilterConcurrent(repository Repository) bool {
+	return filter.applyFilters(repository, true)
+}
+
 // analysePath extracts repositories from regular file paths.
 func analysePath(filter RepositoryFilter, reposChannel chan Repository) filepath.WalkFunc {
 	no_of_repositories := 0
@@ -99,20 +123,10 @@ func analysePath(filter RepositoryFilter, reposChannel chan Repository) filepath
 		}
 
 		repository, foundRepository := NewRepository(no_of_repositories, name, gitPath)
+		repository.root = filter.rootDirectory
 
 		if foundRepository {
-			var allow = true
-			for _, filter := range filter.filters {
-				if filter.FilterRepository(repository) == false {
-					allow = false
-					if filterdef, ok := filter.(FilterDefinition); ok {
-						log.Printf("Skipping repository \"%s\" (filtered by %v)", name, filterdef.Name())
-					}
-					break
-				}
-			}
-
-			if allow {
+			if filter.applyFilters(repository, false) {
 				log.Printf("Found repository \"%s\"", name)
 				no_of_repositories++
 				reposChannel <- repository
@@ -12,13 +12,24 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
-	"text/template"
+	"time"
 )
 
+var remoteRegexp *regexp.Regexp
+
+func init() {
+	remoteRegexp = regexp.MustCompile("^remote \"(.+)\"$")
+}
+
 type Repository struct {
 	index int    // order in which repository was found
+	total int    // total number of repositories found, 0 if not known yet
 	name  string // assumed name of the repo
+	root  string // root directory the repository was found in
 
 	path    string // root work directory
 	gitRoot string // actual git location
@@ -27,11 +38,28 @@ type Repository struct {
 	currentBranch string // store the current branch
 	status        string // store the porcelain status
 
+	haveLastCommit bool    // detect if we retrieved the last commit already
+	lastCommit     *Commit // store the last commit, nil if there is none
+
 	config go_ini.File // stored config
 
 	info map[string]interface{} // let commands store info from a run here
 }
 
+// Commit describes a single commit.
+type Commit struct {
+	Hash    string
+	Author  string
+	Email   string
+	Time    time.Time
+	Subject string
+}
+
+// Date returns the commit time like git shows it in iso format.
+func (commit Commit) Date() string {
+	return commit.Time.Format("2006-01-02 15:04:05 -0700")
+}
+
 type ByIndex []Repository
 
 func (a ByIndex) Len() int           { return len(a) }
@@ -80,9 +108,42 @@ func (repository *Repository) GetConfig() go_ini.File {
 	return repository.config
 }
 
+// GetRemotes returns the remotes with their url.
+func (repository *Repository) GetRemotes() (remotes map[string]string) {
+	remotes = make(map[string]string)
+
+	for name, vars := range repository.config {
+		match := remoteRegexp.FindStringSubmatch(name)
+		if len(match) >= 2 {
+			if value, ok := vars["url"]; ok {
+				remotes[match[1]] = value
+			}
+		}
+	}
+
+	return remotes
+}
+
+// IsBare returns true if the repository has no work directory.
+func (repository *Repository) IsBare() bool {
+	if value, ok := repository.config.Get("core", "bare"); ok {
+		return value == "true"
+	}
+	return false
+}
+
+// GetShell returns the shell to run scripts with, $SHELL or /bin/sh.
+func GetShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
 func (repository Repository) ExecGit(args ...string) (result string, err error, ok bool) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = repository.path
+	cmd.Env = append(os.Environ(), repository.GetEnvironment()...)
 
 	log.Printf("[%s] executing git with arguments %v", repository.GetShowName(), args)
 
@@ -99,6 +160,7 @@ func (repository Repository) ExecGit(args ...string) (result string, err error,
 func (repository Repository) ExecGitInteractive(args ...string) (ok bool) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = repository.path
+	cmd.Env = append(os.Environ(), repository.GetEnvironment()...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -127,6 +189,40 @@ func (repository *Repository) RetrieveBasics() {
 
 }
 
+// RetrieveLastCommit retrieves the last commit on HEAD.
+func (repository *Repository) RetrieveLastCommit() {
+	repository.lastCommit = nil
+
+	if log, _, ok := repository.ExecGit("log", "--max-count=1", "--format=%H : %an : %ae : %at : %s"); ok {
+		results := strings.SplitN(strings.TrimRight(log, "\r\n"), " : ", 5)
+		if len(results) == 5 {
+			if unixtime, err := strconv.ParseInt(results[3], 10, 64); err == nil {
+				repository.lastCommit = &Commit{
+					Hash:    results[0],
+					Author:  results[1],
+					Email:   results[2],
+					Time:    time.Unix(unixtime, 0),
+					Subject: results[4],
+				}
+			}
+		}
+	}
+
+	repository.haveLastCommit = true
+}
+
+// GetLastCommit returns the last commit on HEAD.
+// return bool false if there are no commits.
+func (repository *Repository) GetLastCommit() (Commit, bool) {
+	if !repository.haveLastCommit {
+		repository.RetrieveLastCommit()
+	}
+	if repository.lastCommit == nil {
+		return Commit{}, false
+	}
+	return *repository.lastCommit, true
+}
+
 // NameContains returns true if name contains search.
 func (repository *Repository) NameContains(search string) bool {
 	if strings.Contains(repository.name, search) {
@@ -153,6 +249,11 @@ func (repository *Repository) GetPath() string {
 	return repository.path
 }
 
+// GetRoot returns the root directory the repository was found in.
+func (repository *Repository) GetRoot() string {
+	return repository.root
+}
+
 // GetCurrentBranch returns the current branch.
 func (repository *Repository) GetCurrentBranch() string {
 	if !repository.haveBasics {
@@ -215,26 +316,52 @@ func (repository *Repository) GetInfo(name string) interface{} {
 	return repository.info[name]
 }
 
-// ReplaceMacros replaces macros from the arguments and returns the strings with replacements.
-func (repository Repository) ReplaceMacros(args []string) (out []string) {
-	out = make([]string, len(args))
-
-	macros := make(map[string]string)
-	macros["Name"] = repository.name
-	macros["Path"] = repository.GetPath()
-	macros["CurrentBranch"] = repository.GetCurrentBranch()
+// SetPosition sets the position of the repository once all repositories are known.
+func (repository *Repository) SetPosition(index, total int) {
+	repository.index = index
+	repository.total = total
+}
 
-	for idx, arg := range args {
-		out[idx] = ""
-		if t, err := template.New("arg").Parse(arg); err == nil {
-			b := new(bytes.Buffer)
-			if err := t.Execute(b, macros); err == nil {
-				out[idx] = b.String()
-			} else {
-				log.Fatal(err)
-			}
+// readHeadBranch returns the current branch without running git.
+// Like "git rev-parse --abbrev-ref HEAD" it returns "HEAD" when detached.
+func (repository *Repository) readHeadBranch() string {
+	if content, err := ioutil.ReadFile(repository.gitRoot + "/HEAD"); err == nil {
+		head := strings.TrimSpace(string(content))
+		if strings.HasPrefix(head, "ref: refs/heads/") {
+			return strings.TrimPrefix(head, "ref: refs/heads/")
 		}
+		return "HEAD"
+	}
+	return ""
+}
+
+// GetEnvironment returns environment variables describing the repository for child processes.
+// MGIT_INDEX (starting at 1) and MGIT_TOTAL are only set once all repositories are known.
+func (repository *Repository) GetEnvironment() []string {
+	branch := repository.currentBranch
+	if !repository.haveBasics {
+		// we can't use git here, because we are used by git
+		branch = repository.readHeadBranch()
 	}
 
-	return out
+	env := []string{
+		"MGIT_NAME=" + repository.name,
+		"MGIT_PATH=" + AbsPath(repository.path),
+		"MGIT_GITDIR=" + AbsPath(repository.gitRoot),
+		"MGIT_BRANCH=" + branch,
+		"MGIT_ROOT=" + AbsPath(repository.root),
+	}
+	if repository.total > 0 {
+		env = append(env, "MGIT_INDEX="+strconv.Itoa(repository.index+1), "MGIT_TOTAL="+strconv.Itoa(repository.total))
+	}
+
+	return env
+}
+
+// AbsPath returns the absolute path, for use after the current directory changes or in child processes.
+func AbsPath(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
 }
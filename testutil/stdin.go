@@ -0,0 +1,27 @@
+// Copyright (c) 2014 Marcel Wouters
+
+// Package testutil implements helpers shared by the tests of the other packages.
+package testutil
+
+import (
+	"os"
+	"testing"
+)
+
+// WithStdin runs fn with a pipe (not a terminal) as stdin which returns input.
+func WithStdin(t *testing.T, input string, fn func()) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	writer.WriteString(input)
+	writer.Close()
+
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+
+	fn()
+}